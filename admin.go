@@ -0,0 +1,361 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/account"
+	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/player"
+)
+
+const adminUsage = `Usage: -type admin -config <file> <command> [arguments]
+
+Commands:
+  create-account <username> <password> [gender] [dob yyyymmdd]
+  set-admin <username> <level>
+  give-item <character> <item id> [amount]   (needs Data.nx, character must be offline)
+  unstick <character>                        (clears channel, migration and login state)
+  list-characters <username>
+  restore-character <character>              (undoes a deletion that has not been purged yet)
+`
+
+// slot size columns by inventory id
+var inventorySlotColumns = map[byte]string{1: "equipSlotSize", 2: "useSlotSize", 3: "setupSlotSize", 4: "etcSlotSize", 5: "cashSlotSize"}
+
+type adminTool struct {
+	db *sql.DB
+}
+
+func runAdmin(configFile string, args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+
+	dbConfig := adminConfigFromFile(configFile)
+
+	db, err := sql.Open("mysql", dbConfig.User+":"+dbConfig.Password+"@tcp("+dbConfig.Address+":"+dbConfig.Port+")/"+dbConfig.Database)
+
+	if err == nil {
+		err = db.Ping()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to connect to database:", err)
+		os.Exit(1)
+	}
+
+	defer db.Close()
+
+	tool := adminTool{db: db}
+
+	commands := map[string]struct {
+		minArgs int
+		run     func([]string) error
+	}{
+		"create-account":    {2, tool.createAccount},
+		"set-admin":         {2, tool.setAdmin},
+		"give-item":         {2, tool.giveItem},
+		"unstick":           {1, tool.unstick},
+		"list-characters":   {1, tool.listCharacters},
+		"restore-character": {1, tool.restoreCharacter},
+	}
+
+	command, ok := commands[args[0]]
+
+	if !ok || len(args)-1 < command.minArgs {
+		fmt.Fprint(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+
+	if err := command.run(args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func (tool adminTool) createAccount(args []string) error {
+	r := account.Registration{Username: args[0], Password: args[1]}
+
+	if len(args) > 2 {
+		gender, err := strconv.ParseUint(args[2], 10, 8)
+
+		if err != nil {
+			return account.ErrInvalidGender
+		}
+
+		r.Gender = byte(gender)
+	}
+
+	if len(args) > 3 {
+		dob, err := strconv.ParseInt(args[3], 10, 32)
+
+		if err != nil {
+			return account.ErrInvalidDob
+		}
+
+		r.Dob = int32(dob)
+	}
+
+	id, err := account.Create(tool.db, r)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created account %s with id %d\n", r.Username, id)
+
+	return nil
+}
+
+func (tool adminTool) setAdmin(args []string) error {
+	level, err := strconv.ParseInt(args[1], 10, 8)
+
+	if err != nil || level < 0 {
+		return errors.New("level must be a number from 0")
+	}
+
+	var accountID int32
+	var loggedIn bool
+	err = tool.db.QueryRow("SELECT accountID, isLogedIn FROM accounts WHERE username=?", args[0]).Scan(&accountID, &loggedIn)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no account named %s", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	if loggedIn {
+		return fmt.Errorf("%s is logged in, log them out first", args[0])
+	}
+
+	if _, err := tool.db.Exec("UPDATE accounts SET adminLevel=? WHERE accountID=?", level, accountID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set admin level of %s to %d\n", args[0], level)
+
+	return nil
+}
+
+// offlineCharacter looks up a character by name and refuses it if it or its account is online
+func (tool adminTool) offlineCharacter(name string) (int32, error) {
+	var charID int32
+	var channelID, migrationID int8
+	var loggedIn bool
+	var deletedAt sql.NullInt64
+
+	err := tool.db.QueryRow("SELECT c.id, c.channelID, c.migrationID, c.deletedAt, a.isLogedIn FROM characters c JOIN accounts a ON a.accountID=c.accountID WHERE c.name=?", name).
+		Scan(&charID, &channelID, &migrationID, &deletedAt, &loggedIn)
+
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no character named %s", name)
+	} else if err != nil {
+		return 0, err
+	}
+
+	if deletedAt.Valid {
+		return 0, fmt.Errorf("%s is deleted, restore them first", name)
+	}
+
+	if channelID != -1 || migrationID != -1 || loggedIn {
+		return 0, fmt.Errorf("%s is online, use unstick if they are not really connected", name)
+	}
+
+	return charID, nil
+}
+
+func (tool adminTool) giveItem(args []string) error {
+	itemID, err := strconv.ParseInt(args[1], 10, 32)
+
+	if err != nil {
+		return errors.New("item id must be a number")
+	}
+
+	amount := int64(1)
+
+	if len(args) > 2 {
+		amount, err = strconv.ParseInt(args[2], 10, 16)
+
+		if err != nil || amount < 1 {
+			return errors.New("amount must be a number from 1")
+		}
+	}
+
+	charID, err := tool.offlineCharacter(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	nx.LoadFile("Data.nx")
+
+	newItem, err := item.CreateFromID(int32(itemID), int16(amount))
+
+	if err != nil {
+		return err
+	}
+
+	slot, err := tool.freeSlot(charID, newItem.InvID())
+
+	if err != nil {
+		return err
+	}
+
+	newItem.SetSlotID(slot)
+
+	if _, err := newItem.Save(tool.db, charID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Gave %s %d x %d in inventory %d slot %d\n", args[0], amount, itemID, newItem.InvID(), slot)
+
+	return nil
+}
+
+// freeSlot returns the first empty slot in the character's inventory
+func (tool adminTool) freeSlot(charID int32, invID byte) (int16, error) {
+	column, ok := inventorySlotColumns[invID]
+
+	if !ok {
+		return 0, fmt.Errorf("unknown inventory %d", invID)
+	}
+
+	var size int16
+	if err := tool.db.QueryRow("SELECT "+column+" FROM characters WHERE id=?", charID).Scan(&size); err != nil {
+		return 0, err
+	}
+
+	rows, err := tool.db.Query("SELECT slotNumber FROM items WHERE characterID=? AND inventoryID=? AND slotNumber > 0", charID, invID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	used := make(map[int16]bool)
+
+	for rows.Next() {
+		var slot int16
+
+		if err := rows.Scan(&slot); err != nil {
+			return 0, err
+		}
+
+		used[slot] = true
+	}
+
+	for slot := int16(1); slot <= size; slot++ {
+		if !used[slot] {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("inventory %d is full", invID)
+}
+
+func (tool adminTool) unstick(args []string) error {
+	var charID, accountID int32
+	err := tool.db.QueryRow("SELECT id, accountID FROM characters WHERE name=?", args[0]).Scan(&charID, &accountID)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no character named %s", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tool.db.Exec("UPDATE characters SET channelID=-1, migrationID=-1 WHERE id=?", charID); err != nil {
+		return err
+	}
+
+	if _, err := tool.db.Exec("UPDATE accounts SET isLogedIn=0 WHERE accountID=?", accountID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Unstuck %s, their account can log in again\n", args[0])
+
+	return nil
+}
+
+func (tool adminTool) listCharacters(args []string) error {
+	rows, err := tool.db.Query("SELECT c.id, c.name, c.worldID, c.level, c.job, c.mapID, c.channelID, c.deletedAt FROM characters c JOIN accounts a ON a.accountID=c.accountID WHERE a.username=? ORDER BY c.id", args[0])
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tWORLD\tLEVEL\tJOB\tMAP\tSTATUS")
+
+	count := 0
+
+	for rows.Next() {
+		var id, mapID int32
+		var name string
+		var worldID byte
+		var level byte
+		var job int16
+		var channelID int8
+		var deletedAt sql.NullInt64
+
+		if err := rows.Scan(&id, &name, &worldID, &level, &job, &mapID, &channelID, &deletedAt); err != nil {
+			return err
+		}
+
+		status := "offline"
+
+		if deletedAt.Valid {
+			purge := time.Unix(deletedAt.Int64+constant.CharacterDeleteGracePeriod, 0)
+			status = "deleted (purged after " + purge.Format("2006-01-02 15:04") + ")"
+		} else if channelID == constant.CashShopID {
+			status = "online (cash shop)"
+		} else if channelID != -1 {
+			status = "online (channel " + strconv.Itoa(int(channelID)) + ")"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%s\n", id, name, worldID, level, job, mapID, status)
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	w.Flush()
+
+	if count == 0 {
+		fmt.Println("No characters found for", args[0])
+	}
+
+	return nil
+}
+
+func (tool adminTool) restoreCharacter(args []string) error {
+	var charID int32
+	err := tool.db.QueryRow("SELECT id FROM characters WHERE name=? AND deletedAt IS NOT NULL", args[0]).Scan(&charID)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no deleted character named %s, it may have already been purged", args[0])
+	} else if err != nil {
+		return err
+	}
+
+	if err := player.Restore(tool.db, charID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s\n", args[0])
+
+	return nil
+}
@@ -1,5 +1,11 @@
 package constant
 
+// Limits on the worlds the login server lists and the channels a world can have
+const (
+	MaxWorlds   = 15
+	MaxChannels = 20
+)
+
 var WORLD_NAMES = [...]string{"Scania", "Bera", "Broa", "Windia", "Khaini", "Bellocan", "Mardia", "Kradia", "Yellonde", "Demethos", "Galicia", "El Nido", "Zenith", "Arcania", "Chaos", "Nova", "Renegates"}
 
 // Generic Constants
@@ -10,6 +16,20 @@ const (
 	OpcodeLength          = 1
 )
 
+// Cash shop constants
+const (
+	CashShopID          = 50 // channel/migration id used for characters in the cash shop
+	CashShopStorageSize = 100
+
+	CashShopCurrencyNX          = 1
+	CashShopCurrencyMaplePoints = 2
+
+	CashShopBuyItem     = 0x03
+	CashShopGiftItem    = 0x04
+	CashShopTakeOutItem = 0x0D
+	CashShopPutInItem   = 0x0E
+)
+
 const (
 	MaxItemStack = 200
 
@@ -117,3 +137,169 @@ var ExpTable = [...]int32{15, 34, 57, 92, 135, 372, 560, 840, 1242, 1144, // Beg
 	894634784, 943660770, 995373379, 1049919840, 1107455447, 1168144006, 1232158297, 1299680571,
 	1370903066, 1446028554, 1525246918, 1608855764, 1697021059, // 0 is the amount of exp needed for level 200 to level up i.e. never shall
 }
+
+const (
+	PetMaxLevel     = 30
+	PetMaxFullness  = 100
+	PetMaxCloseness = 30000
+
+	PetFoodFullness     = 30  // fullness restored by a single piece of pet food
+	PetHungerInterval   = 60  // seconds it takes a pet with a hunger rate of one to lose a point of fullness
+	PetPickupRange      = 150 // range around a pet it can loot within
+	PetSweepPickupRange = 400 // range around a pet with a sweep for drop item equipped
+	PetFoodItemType     = 212 // itemID / 1e4 of pet food
+	PetEquipItemType    = 180 // itemID / 1e4 of pet equipment
+	PetEquipSlot        = -14 // equip slot pet equipment is worn in
+	PetNameTagItemID    = 5060000
+
+	DropExpireTime        = 180 // seconds before a drop disappears from the field
+	DropFreeForAllTime    = 30  // seconds before anyone can pick up a drop
+	DropPlayerPickupRange = 200 // range around a player they can pick up drops within
+
+	MysticDoorUseRange = 100 // range around a mystic door a player can enter it from
+
+	EventGatherRange = 300 // range around the player starting an event that others join it from
+)
+
+// Portal types found in map data
+const (
+	PortalStart                 = 0
+	PortalInvisible             = 1
+	PortalVisible               = 2
+	PortalCollision             = 3
+	PortalChangeable            = 4
+	PortalChangeableInvisible   = 5
+	PortalTownPortal            = 6
+	PortalScript                = 7
+	PortalScriptInvisible       = 8
+	PortalCollisionScript       = 9
+	PortalHidden                = 10
+	PortalScriptHidden          = 11
+	PortalCollisionVerticalJump = 12
+	PortalCollisionCustomImpact = 13
+
+	PortalUseRangeX          = 100 // range around a portal the player can enter it from, accounting for lag
+	PortalUseRangeY          = 10
+	PortalCollisionUseRangeX = 150 // collision portals trigger on the edge of the player's body
+	PortalCollisionUseRangeY = 60
+	PortalScriptTimeout      = 100 // milliseconds a portal script can run for before it is stopped
+)
+
+// Field limit flags found in map data
+const (
+	FieldLimitJump           = 0x01
+	FieldLimitMovementSkill  = 0x02
+	FieldLimitSummon         = 0x04
+	FieldLimitMysticDoor     = 0x08
+	FieldLimitChangeChannel  = 0x10
+	FieldLimitRegularExpLoss = 0x20
+	FieldLimitTeleportItem   = 0x40
+	FieldLimitMiniGame       = 0x80
+	FieldLimitConsumeItem    = 0x1000
+
+	FieldDecHPInterval = 10 // seconds between hp drains in maps with decHP
+	FieldNoReturnMap   = 999999999
+)
+
+// Movement limits used when validating player movement, speeds are in pixels per second at 100%
+const (
+	MovementBaseSpeed      = 125
+	MovementBaseJump       = 555
+	MovementMaxFallSpeed   = 670
+	MovementFlashJumpSpeed = 600 // horizontal speed of a flash jump, not affected by the speed stat
+	MovementMaxSpeedStat   = 140
+	MovementMaxJumpStat    = 123
+	MovementTolerance      = 30  // pixels of leeway given for lag and rounding
+	MovementSkillRange     = 350 // furthest a teleport or assaulter skill can move a player
+	MovementMaxElapsed     = 2   // seconds since the last accepted movement that count towards how far a player can have moved
+	MovementSuspicionAlert = 10  // suspicion score at which gms are told about a player
+)
+
+// Limits used when validating player attacks
+const (
+	AttackMinInterval    = 250 // milliseconds allowed between attacks, allowing for packets bunching up on lag
+	AttackDamageLeeway   = 1.5 // multiplier on max damage to cover criticals and buffs
+	AttackMaxDamage      = 199999
+	AttackMeleeRangeX    = 150 // reach of a basic melee attack
+	AttackMeleeRangeY    = 100
+	AttackRangeTolerance = 50 // pixels of leeway for mobs moving between client and server
+)
+
+// Fame limits
+const (
+	FameMinLevel     = 15
+	FameDailyLimit   = 1  // fame that can be given per day
+	FameTargetPeriod = 30 // days before the same character can be famed again
+)
+
+// Ban reasons shown to the player when their login is rejected
+const (
+	BanReasonHacking            = 1
+	BanReasonBotting            = 2
+	BanReasonAdvertising        = 3
+	BanReasonHarassment         = 4
+	BanReasonProfanity          = 5
+	BanReasonScamming           = 6
+	BanReasonMisconduct         = 7
+	BanReasonIllegalTransaction = 8
+	BanReasonIllegalCharging    = 9
+	BanReasonTemporary          = 10
+	BanReasonImpersonatingGM    = 11
+	BanReasonIllegalPrograms    = 12
+)
+
+// BanPermanentFiletime is the end date sent for permanent bans
+const BanPermanentFiletime = 150842304000000000
+
+// MigrationTicketExpiry is how many seconds a client has to connect to the server it is migrating to
+const MigrationTicketExpiry = 30
+
+// MigrationTicketWait is how many seconds a connection is held for when it arrives before its ticket
+const MigrationTicketWait = 5
+
+// Login throttling, times are in seconds
+const (
+	LoginMaxFailures     = 5 // failed logins before an account or ip is locked out
+	LoginLockoutTime     = 900
+	LoginBackoffBase     = 1 // wait after the first failure, doubling each failure after
+	LoginBackoffMax      = 30
+	LoginAttemptWindow   = 3600 // failures older than this are forgotten
+	LoginMaxPendingPerIP = 5    // connections from one ip that have not logged in
+	LoginIdleTimeout     = 120  // time a connection has to log in
+)
+
+// Account registration rules, the window is in seconds
+const (
+	AccountNameMinLength      = 4
+	AccountNameMaxLength      = 12
+	AccountPasswordMinLength  = 4
+	AccountPasswordMaxLength  = 12
+	AccountRegistrationsPerIP = 3 // accounts one ip can register within the window
+	AccountRegistrationWindow = 86400
+)
+
+// Deleted characters can be restored and keep their name for the grace period, times are in seconds
+const (
+	CharacterDeleteGracePeriod = 604800
+	CharacterPurgeInterval     = 3600 // how often the login server purges characters past the grace period
+)
+
+// Login pin settings
+const (
+	LoginPinLength      = 4
+	LoginPinMaxAttempts = 5 // wrong pins before the client is disconnected
+)
+
+// Stat roll limits on character creation
+const (
+	CharacterCreationStatTotal = 25
+	CharacterCreationStatMin   = 4
+	CharacterCreationStatMax   = 13
+)
+
+// ChairItemType is the item id prefix of portable chairs in the setup inventory
+const ChairItemType = 301
+
+// PetClosenessTable of closeness required to reach the next pet level
+var PetClosenessTable = [...]int16{1, 3, 6, 14, 31, 60, 108, 181, 287, 434, 632, 891, 1224, 1642, 2161,
+	2793, 3557, 4467, 5542, 6801, 8263, 9950, 11882, 14084, 16578, 19391, 22547, 26074, 30000}
@@ -10,4 +10,14 @@ const (
 	ChannelBad            byte = 0x06
 	ChannelInfo           byte = 0x07
 	ChannelConnectionInfo byte = 0x08
+	CashShopNew           byte = 0x09
+	CashShopOk            byte = 0x0A
+	CashShopBad           byte = 0x0B
+	CashShopInfo          byte = 0x0C
+	DisconnectAccount     byte = 0x0D
+	MigrationTicket       byte = 0x0E
+	WorldRates            byte = 0x0F
+	WorldMessage          byte = 0x10
+	WorldRibbon           byte = 0x11
+	ChangeRates           byte = 0x12
 )
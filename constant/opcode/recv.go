@@ -5,7 +5,7 @@ const (
 	RecvLoginChannelSelect         byte = 0x04
 	RecvLoginWorldSelect           byte = 0x05
 	RecvLoginCheckLogin            byte = 0x08
-	RecvLoginCreateCharacter       byte = 0x09
+	RecvLoginRegisterPin           byte = 0x09
 	RecvLoginSelectCharacter       byte = 0x0B
 	RecvChannelPlayerLoad          byte = 0x0C
 	RecvLoginNameCheck             byte = 0x0D
@@ -33,6 +33,7 @@ const (
 	RecvChannelPassiveRegen        byte = 0x37
 	RecvChannelAddSkillPoint       byte = 0x38
 	RecvChannelSpecialSkill        byte = 0x39
+	RecvChannelGiveFame            byte = 0x3D
 	RecvChannelCharacterInfo       byte = 0x3F
 	RecvChannelLieDetectorResult   byte = 0x45
 	RecvChannelCharacterReport     byte = 0x49
@@ -43,6 +44,17 @@ const (
 	RecvChannelGuildReject         byte = 0x52
 	RecvChannelAddBuddy            byte = 0x55
 	RecvChannelUseMysticDoor       byte = 0x58
+	RecvChannelScriptedPortal      byte = 0x59
+	RecvChannelSpawnPet            byte = 0x5A
+	RecvChannelPetMovement         byte = 0x5B
+	RecvChannelPetChat             byte = 0x5C
+	RecvChannelPetCommand          byte = 0x5D
+	RecvChannelPetLoot             byte = 0x5E
+	RecvChannelPetFood             byte = 0x5F
+	RecvChannelPetNameChange       byte = 0x60
+	RecvChannelDropPickUp          byte = 0x62
 	RecvChannelMobControl          byte = 0x6A
 	RecvChannelNpcMovement         byte = 0x6F
+	RecvCashShopQueryCash          byte = 0x7A
+	RecvCashShopOperation          byte = 0x7B
 )
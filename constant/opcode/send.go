@@ -3,8 +3,8 @@ package opcode
 const (
 	SendLoginResponce               byte = 0x01
 	SendLoginWorldMeta              byte = 0x03
-	SendLoginPinRegister            byte = 0x07 // Add 1 byte, 1 = register byte a pin
-	SendLoginPinStuff               byte = 0x08 // Setting byte pin good
+	SendLoginPinOperation           byte = 0x07 // 0 accepted, 1 register, 2 invalid, 3 failed, 4 enter pin
+	SendLoginPinAssigned            byte = 0x08
 	SendLoginWorldList              byte = 0x09
 	SendLoginCharacterData          byte = 0x0A
 	SendLoginCharacterMigrate       byte = 0x0B
@@ -16,6 +16,7 @@ const (
 	SendChannelInventoryOperation   byte = 0x18
 	SendChannelStatChange           byte = 0x1A
 	SendChannelSkillRecordUpdate    byte = 0x1D
+	SendChannelFameResponse         byte = 0x1F
 	SendChannelInfoMessage          byte = 0x20
 	SendChannelLieDetectorTest      byte = 0x23
 	SendChannelAvatarInfoWindow     byte = 0x2c
@@ -24,6 +25,7 @@ const (
 	SendChannelTownPortal           byte = 0x31
 	SendChannelBroadcastMessage     byte = 0x32
 	SendChannelWarpToMap            byte = 0x36
+	SendChannelSetCashShop          byte = 0x37
 	SendChannelPortalClosed         byte = 0x3A
 	SendChannelChangeServer         byte = 0x3B
 	SendChannelBubblessChat         byte = 0x3D
@@ -32,6 +34,8 @@ const (
 	SendChannelEmployee             byte = 0x43
 	SendChannelQuizQAndA            byte = 0x44
 	SendChannelCountdown            byte = 0x46
+	SendChannelContiMove            byte = 0x47
+	SendChannelContiState           byte = 0x48
 	SendChannelCharacterEnterField  byte = 0x4E
 	SendChannelCharacterLeaveField  byte = 0x4F
 	SendChannelAllChatMsg           byte = 0x51
@@ -42,8 +46,15 @@ const (
 	SendChannelPlayerUseMagicSkill  byte = 0x68
 	SendChannelPlayerTakeDmg        byte = 0x6B
 	SendChannelPlayerEmoticon       byte = 0x6C
+	SendChannelPlayerShowChair      byte = 0x6E
 	SendChannelPlayerChangeAvatar   byte = 0x6F
 	SendChannelPlayerAnimation      byte = 0x70
+	SendChannelPetSpawn             byte = 0x71
+	SendChannelPetMove              byte = 0x72
+	SendChannelPetAction            byte = 0x73
+	SendChannelPetNameChange        byte = 0x74
+	SendChannelPetCommandResponse   byte = 0x75
+	SendChannelPlayerSit            byte = 0x78
 	SendChannelLevelUpAnimation     byte = 0x79
 	SendChannelShowMob              byte = 0x86
 	SendChannelRemoveMob            byte = 0x87
@@ -56,6 +67,7 @@ const (
 	SendChannelNpcControl           byte = 0x99
 	SendChannelNpcMovement          byte = 0x9B
 	SendChannelDrobEnterMap         byte = 0xA4
+	SendChannelDropExitMap          byte = 0xA5
 	SendChannelSpawnDoor            byte = 0xB1
 	SendChannelRemoveDoor           byte = 0xB2
 	SendChannelNpcDialogueBox       byte = 0xC5
@@ -63,4 +75,6 @@ const (
 	SendChannelNpcShopResult        byte = 0xC9
 	SendChannelNpcStorage           byte = 0xCD
 	SendChannelRoom                 byte = 0xDC
+	SendCashShopQueryCashResult     byte = 0xEE
+	SendCashShopOperation           byte = 0xEF
 )
@@ -1,5 +1,7 @@
 package skills
 
+import "github.com/Hucaru/Valhalla/constant"
+
 type warrior struct {
 }
 
@@ -9,6 +11,9 @@ type bowman struct {
 type mage struct {
 }
 
+// MysticDoor priest skill that links the cast position with town
+const MysticDoor int32 = 2311002
+
 type thief struct {
 }
 
@@ -17,3 +22,42 @@ type gm struct {
 
 type superGm struct {
 }
+
+// Advancement into a job, the requirements the player must meet and what they gain
+type Advancement struct {
+	From               int16 // job the player must currently have
+	Level              byte
+	Str, Dex, Int, Luk int16
+	SP                 int16
+	HP, MP             int16 // added to max hp and max mp
+}
+
+// Advancements keyed by the job being advanced into, jobs not in here cannot be reached by advancing
+var Advancements = map[int16]Advancement{
+	constant.WarriorJobID:  {From: constant.BeginnerJobID, Level: 10, Str: 35, SP: 1, HP: 200},
+	constant.MagicianJobID: {From: constant.BeginnerJobID, Level: 8, Int: 20, SP: 1, MP: 100},
+	constant.BowmanJobID:   {From: constant.BeginnerJobID, Level: 10, Dex: 25, SP: 1, HP: 100, MP: 25},
+	constant.ThiefJobID:    {From: constant.BeginnerJobID, Level: 10, Dex: 25, SP: 1, HP: 100, MP: 25},
+
+	constant.FighterJobID:          {From: constant.WarriorJobID, Level: 30, SP: 1, HP: 300},
+	constant.PageJobID:             {From: constant.WarriorJobID, Level: 30, SP: 1, HP: 300},
+	constant.SpearmanJobID:         {From: constant.WarriorJobID, Level: 30, SP: 1, HP: 300},
+	constant.FirePoisonWizardJobID: {From: constant.MagicianJobID, Level: 30, SP: 1, MP: 450},
+	constant.IceLightWizardJobID:   {From: constant.MagicianJobID, Level: 30, SP: 1, MP: 450},
+	constant.ClericJobID:           {From: constant.MagicianJobID, Level: 30, SP: 1, MP: 450},
+	constant.HunterJobID:           {From: constant.BowmanJobID, Level: 30, SP: 1, HP: 300, MP: 150},
+	constant.CrossbowmanJobID:      {From: constant.BowmanJobID, Level: 30, SP: 1, HP: 300, MP: 150},
+	constant.AssassinJobID:         {From: constant.ThiefJobID, Level: 30, SP: 1, HP: 300, MP: 150},
+	constant.BanditJobID:           {From: constant.ThiefJobID, Level: 30, SP: 1, HP: 300, MP: 150},
+
+	constant.CrusaderJobID:       {From: constant.FighterJobID, Level: 70, SP: 1, HP: 300},
+	constant.WhiteKnightJobID:    {From: constant.PageJobID, Level: 70, SP: 1, HP: 300},
+	constant.DragonKnightJobID:   {From: constant.SpearmanJobID, Level: 70, SP: 1, HP: 300},
+	constant.FirePoisonMageJobID: {From: constant.FirePoisonWizardJobID, Level: 70, SP: 1, MP: 450},
+	constant.IceLightMageJobID:   {From: constant.IceLightWizardJobID, Level: 70, SP: 1, MP: 450},
+	constant.PriestJobID:         {From: constant.ClericJobID, Level: 70, SP: 1, MP: 450},
+	constant.RangerJobID:         {From: constant.HunterJobID, Level: 70, SP: 1, HP: 300, MP: 150},
+	constant.SniperJobID:         {From: constant.CrossbowmanJobID, Level: 70, SP: 1, HP: 300, MP: 150},
+	constant.HermitJobID:         {From: constant.AssassinJobID, Level: 70, SP: 1, HP: 300, MP: 150},
+	constant.ChiefBanditJobID:    {From: constant.BanditJobID, Level: 70, SP: 1, HP: 300, MP: 150},
+}
@@ -3,10 +3,12 @@ module github.com/Hucaru/Valhalla
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/Hucaru/gonx v0.0.0-20181222224749-c3d9197c5bdf
+	github.com/dop251/goja v0.0.0-20210406175830-1b11a6af686d
 	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-sql-driver/mysql v1.4.1
 	github.com/google/uuid v1.1.1
 	github.com/prometheus/client_golang v1.6.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/sys v0.0.0-20200427175716-29b57079015a // indirect
 	google.golang.org/appengine v1.4.0 // indirect
 )
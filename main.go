@@ -6,7 +6,7 @@ import (
 )
 
 func main() {
-	typePtr := flag.String("type", "", "Denotes what type of server to start: login, world, channel")
+	typePtr := flag.String("type", "", "Denotes what type of server to start: login, world, channel, cashshop, or admin to run an admin command")
 	configPtr := flag.String("config", "", "config toml file")
 
 	flag.Parse()
@@ -21,6 +21,11 @@ func main() {
 	case "channel":
 		s := newChannelServer(*configPtr)
 		s.run()
+	case "cashshop":
+		s := newCashShopServer(*configPtr)
+		s.run()
+	case "admin":
+		runAdmin(*configPtr, flag.Args())
 	default:
 		log.Println("Unkown server type:", *typePtr)
 	}
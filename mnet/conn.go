@@ -12,6 +12,7 @@ type MConn interface {
 	String() string
 	Send(mpacket.Packet)
 	Cleanup()
+	Close() error
 }
 
 func clientReader(conn net.Conn, eRecv chan *Event, mapleVersion int16, headerSize int, cryptRecv *crypt.Maple) {
@@ -0,0 +1,75 @@
+package nx
+
+import (
+	"log"
+
+	"github.com/Hucaru/gonx"
+)
+
+// Commodity data from nx, these are the items listed in the cash shop
+type Commodity struct {
+	SN       int32
+	ItemID   int32
+	Count    int16
+	Price    int32
+	Period   int16 // days, 0 is permanent
+	Priority int32
+	Gender   byte // 0 - male, 1 - female, 2 - both
+	OnSale   bool
+}
+
+func extractCommodities(nodes []gonx.Node, textLookup []string) map[int32]Commodity {
+	commodities := make(map[int32]Commodity)
+
+	search := "/Etc/Commodity.img"
+	valid := gonx.FindNode(search, nodes, textLookup, func(node *gonx.Node) {
+		for i := uint32(0); i < uint32(node.ChildCount); i++ {
+			commodityNode := nodes[node.ChildID+i]
+			commodity := getCommodity(&commodityNode, nodes, textLookup)
+
+			if commodity.SN == 0 {
+				continue
+			}
+
+			commodities[commodity.SN] = commodity
+		}
+	})
+
+	if !valid {
+		log.Println("Invalid node search:", search)
+	}
+
+	return commodities
+}
+
+func getCommodity(node *gonx.Node, nodes []gonx.Node, textLookup []string) Commodity {
+	commodity := Commodity{Gender: 2}
+
+	for i := uint32(0); i < uint32(node.ChildCount); i++ {
+		option := nodes[node.ChildID+i]
+		optionName := textLookup[option.NameID]
+
+		switch optionName {
+		case "SN":
+			commodity.SN = gonx.DataToInt32(option.Data)
+		case "ItemId":
+			commodity.ItemID = gonx.DataToInt32(option.Data)
+		case "Count":
+			commodity.Count = gonx.DataToInt16(option.Data)
+		case "Price":
+			commodity.Price = gonx.DataToInt32(option.Data)
+		case "Period":
+			commodity.Period = gonx.DataToInt16(option.Data)
+		case "Priority":
+			commodity.Priority = gonx.DataToInt32(option.Data)
+		case "Gender":
+			commodity.Gender = option.Data[0]
+		case "OnSale":
+			commodity.OnSale = gonx.DataToBool(option.Data[0])
+		default:
+			log.Println("Unsupported NX commodity option:", optionName, "->", option.Data)
+		}
+	}
+
+	return commodity
+}
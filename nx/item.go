@@ -20,13 +20,13 @@ type Item struct {
 	ReqJob                                                         int64
 	ReqSTR, ReqDEX, ReqINT, ReqLUK, IncSTR, IncDEX, IncINT, IncLUK int16
 	IncACC, IncEVA, IncMDD, IncPDD, IncMAD, IncPAD, IncMHP, IncMMP float64
-	Attack, IncJump, IncSpeed, RecoveryHP                          float64
+	Attack, IncJump, IncSpeed, RecoveryHP, RecoveryMP              float64
 	AttackSpeed                                                    int16
 	Price                                                          int32
 	NotSale                                                        int64
 	UnitPrice                                                      float64
 	Life, Hungry                                                   int64
-	PickupItem, PickupAll, SweepForDrop                            int64
+	PickupItem, PickupAll, PickupMeso, SweepForDrop                int64
 	ConsumeHP, LongRange                                           int64
 	Recovery                                                       float64
 	ReqPOP                                                         int64 // ?
@@ -52,6 +52,13 @@ type Item struct {
 	Knockback                                                      int64
 	Fs                                                             int64
 	ChatBalloon                                                    int64
+	PetCommands                                                    map[byte]PetCommand
+}
+
+// PetCommand data from nx, used when a player interacts with a pet
+type PetCommand struct {
+	Inc, Prob int64 // closeness increase and chance of success
+	L0, L1    int64 // pet level range the command is available at
 }
 
 func extractItems(nodes []gonx.Node, textLookup []string) map[int32]Item {
@@ -167,6 +174,12 @@ func extractItems(nodes []gonx.Node, textLookup []string) map[int32]Item {
 
 			item.InvTabID = byte(itemID / 1e6)
 			item.Pet = true
+
+			interactSearch := "/Item/Pet/" + name + ".img/interact"
+			gonx.FindNode(interactSearch, nodes, textLookup, func(node *gonx.Node) {
+				item.PetCommands = getPetCommands(node, nodes, textLookup)
+			})
+
 			items[int32(itemID)] = item
 		}
 	})
@@ -230,6 +243,8 @@ func getItem(node *gonx.Node, nodes []gonx.Node, textLookup []string) Item {
 			item.IncMHP = float64(gonx.DataToInt16(option.Data))
 		case "recoveryHP":
 			item.RecoveryHP = float64(gonx.DataToInt16(option.Data))
+		case "recoveryMP":
+			item.RecoveryMP = float64(gonx.DataToInt16(option.Data))
 		case "incMMP":
 			item.IncMMP = float64(gonx.DataToInt16(option.Data))
 		case "only":
@@ -262,6 +277,8 @@ func getItem(node *gonx.Node, nodes []gonx.Node, textLookup []string) Item {
 			item.PickupItem = gonx.DataToInt64(option.Data)
 		case "pickupAll":
 			item.PickupAll = gonx.DataToInt64(option.Data)
+		case "pickupMeso":
+			item.PickupMeso = gonx.DataToInt64(option.Data)
 		case "sweepForDrop":
 			item.SweepForDrop = gonx.DataToInt64(option.Data)
 		case "longRange":
@@ -339,3 +356,41 @@ func getItem(node *gonx.Node, nodes []gonx.Node, textLookup []string) Item {
 
 	return item
 }
+
+func getPetCommands(node *gonx.Node, nodes []gonx.Node, textLookup []string) map[byte]PetCommand {
+	commands := make(map[byte]PetCommand)
+
+	for i := uint32(0); i < uint32(node.ChildCount); i++ {
+		commandNode := nodes[node.ChildID+i]
+		id, err := strconv.Atoi(textLookup[commandNode.NameID])
+
+		if err != nil {
+			continue
+		}
+
+		var command PetCommand
+
+		for j := uint32(0); j < uint32(commandNode.ChildCount); j++ {
+			option := nodes[commandNode.ChildID+j]
+			optionName := textLookup[option.NameID]
+
+			switch optionName {
+			case "inc":
+				command.Inc = gonx.DataToInt64(option.Data)
+			case "prob":
+				command.Prob = gonx.DataToInt64(option.Data)
+			case "l0":
+				command.L0 = gonx.DataToInt64(option.Data)
+			case "l1":
+				command.L1 = gonx.DataToInt64(option.Data)
+			case "command":
+			default:
+				log.Println("Unsupported NX pet command option:", optionName, "->", option.Data)
+			}
+		}
+
+		commands[byte(id)] = command
+	}
+
+	return commands
+}
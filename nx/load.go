@@ -11,6 +11,8 @@ var maps map[int32]Map
 var mobs map[int32]Mob
 var playerSkills map[int32][]PlayerSkill
 var mobSkills map[byte][]MobSkill
+var commodities map[int32]Commodity
+var makeCharInfo map[byte]MakeCharInfo
 
 // LoadFile into useable types
 func LoadFile(fname string) {
@@ -24,6 +26,8 @@ func LoadFile(fname string) {
 	maps = extractMaps(nodes, textLookup)
 	mobs = extractMobs(nodes, textLookup)
 	playerSkills, mobSkills = extractSkills(nodes, textLookup)
+	commodities = extractCommodities(nodes, textLookup)
+	makeCharInfo = extractMakeCharInfo(nodes, textLookup)
 }
 
 // GetItem from loaded nx
@@ -75,3 +79,26 @@ func GetMobSkill(id byte) ([]MobSkill, error) {
 
 	return mobSkills[id], nil
 }
+
+// GetCommodity from loaded nx
+func GetCommodity(sn int32) (Commodity, error) {
+	if _, ok := commodities[sn]; !ok {
+		return Commodity{}, fmt.Errorf("Invalid commodity sn: %v", sn)
+	}
+
+	return commodities[sn], nil
+}
+
+// GetCommodities from loaded nx
+func GetCommodities() map[int32]Commodity {
+	return commodities
+}
+
+// GetMakeCharInfo for the given gender, 0 is male and 1 female
+func GetMakeCharInfo(gender byte) (MakeCharInfo, error) {
+	if _, ok := makeCharInfo[gender]; !ok {
+		return MakeCharInfo{}, fmt.Errorf("Invalid make char info gender: %v", gender)
+	}
+
+	return makeCharInfo[gender], nil
+}
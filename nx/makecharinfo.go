@@ -0,0 +1,78 @@
+package nx
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/Hucaru/gonx"
+)
+
+// MakeCharInfo is the set of options the client offers when creating a character
+type MakeCharInfo struct {
+	Faces       []int32
+	Hairs       []int32
+	HairColours []int32
+	Skins       []int32
+	Tops        []int32
+	Bottoms     []int32
+	Shoes       []int32
+	Weapons     []int32
+}
+
+// options in the order they appear under each gender node
+func (info *MakeCharInfo) options() []*[]int32 {
+	return []*[]int32{&info.Faces, &info.Hairs, &info.HairColours, &info.Skins, &info.Tops, &info.Bottoms, &info.Shoes, &info.Weapons}
+}
+
+// defaultMakeCharInfo is used when the nx file has no creation options
+var defaultMakeCharInfo = MakeCharInfo{
+	Faces:       []int32{20000, 20001, 20002, 21000, 21001, 21002, 20100, 20401, 20402, 21700, 21201},
+	Hairs:       []int32{30000, 30020, 30030, 31000, 31040, 31050},
+	HairColours: []int32{0, 7, 3, 2},
+	Skins:       []int32{0, 1, 2, 3},
+	Tops:        []int32{1040002, 1040006, 1040010, 1041002, 1041006, 1041010, 1041011, 1042167},
+	Bottoms:     []int32{1060002, 1060006, 1061002, 1061008, 1062115},
+	Shoes:       []int32{1072001, 1072005, 1072037, 1072038, 1072383},
+	Weapons:     []int32{1302000, 1322005, 1312004, 1442079},
+}
+
+func extractMakeCharInfo(nodes []gonx.Node, textLookup []string) map[byte]MakeCharInfo {
+	infos := map[byte]MakeCharInfo{0: defaultMakeCharInfo, 1: defaultMakeCharInfo}
+
+	genders := map[string]byte{"CharMale": 0, "CharFemale": 1}
+
+	for name, gender := range genders {
+		search := "/Etc/MakeCharInfo.img/Info/" + name
+		valid := gonx.FindNode(search, nodes, textLookup, func(node *gonx.Node) {
+			infos[gender] = getMakeCharInfo(node, nodes, textLookup)
+		})
+
+		if !valid {
+			log.Println("Invalid node search:", search)
+		}
+	}
+
+	return infos
+}
+
+func getMakeCharInfo(node *gonx.Node, nodes []gonx.Node, textLookup []string) MakeCharInfo {
+	var info MakeCharInfo
+	options := info.options()
+
+	for i := uint32(0); i < uint32(node.ChildCount); i++ {
+		optionNode := nodes[node.ChildID+i]
+		index, err := strconv.Atoi(textLookup[optionNode.NameID])
+
+		if err != nil || index < 0 || index >= len(options) {
+			log.Println("Unsupported NX make char info option:", textLookup[optionNode.NameID])
+			continue
+		}
+
+		for j := uint32(0); j < uint32(optionNode.ChildCount); j++ {
+			value := nodes[optionNode.ChildID+j]
+			*options[index] = append(*options[index], gonx.DataToInt32(value.Data))
+		}
+	}
+
+	return info
+}
@@ -46,6 +46,7 @@ type Reactor struct {
 
 // Foothold in map
 type Foothold struct {
+	ID             int16
 	X1, X2, Y1, Y2 int
 }
 
@@ -58,8 +59,9 @@ type Map struct {
 
 	Swim, PersonalShop, EntrustedShop, ScrollDisable int64
 
-	MoveLimit int64
-	DecHP     int64
+	MoveLimit   int64
+	DecHP       int64
+	ProtectItem int32
 
 	NPCs      []Life
 	Mobs      []Life
@@ -163,6 +165,8 @@ func getMapInfo(node *gonx.Node, nodes []gonx.Node, textLookup []string) Map {
 			m.MoveLimit = gonx.DataToInt64(option.Data)
 		case "decHP":
 			m.DecHP = gonx.DataToInt64(option.Data)
+		case "protectItem":
+			m.ProtectItem = gonx.DataToInt32(option.Data)
 		case "scrollDisable":
 			m.ScrollDisable = gonx.DataToInt64(option.Data)
 		case "fieldLimit": // Max number of mobs on map?
@@ -376,6 +380,10 @@ func getMapFootholds(node *gonx.Node, nodes []gonx.Node, textLookup []string) []
 
 				foothold := Foothold{}
 
+				if id, err := strconv.Atoi(textLookup[fh.NameID]); err == nil {
+					foothold.ID = int16(id)
+				}
+
 				for u := uint32(0); u < uint32(fh.ChildCount); u++ {
 					option := nodes[fh.ChildID+u]
 					optionName := textLookup[option.NameID]
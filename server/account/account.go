@@ -0,0 +1,199 @@
+// Package account registers new player accounts
+package account
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/server/password"
+)
+
+// Reasons a registration is refused
+var (
+	ErrInvalidName          = errors.New("account name must be 4 to 12 letters or numbers")
+	ErrInvalidPassword      = errors.New("password must be 4 to 12 characters")
+	ErrInvalidGender        = errors.New("gender must be 0 or 1")
+	ErrInvalidDob           = errors.New("date of birth must be yyyymmdd")
+	ErrNameTaken            = errors.New("account name is taken")
+	ErrTooManyRegistrations = errors.New("too many accounts registered from this ip")
+	ErrWrongLogin           = errors.New("account name or password is wrong")
+	ErrDobSet               = errors.New("date of birth is already set")
+	ErrTooManyAttempts      = errors.New("too many failed attempts, try again later")
+)
+
+// Registration details for a new account
+type Registration struct {
+	Username string
+	Password string
+	Gender   byte
+	Dob      int32 // yyyymmdd, asked for when deleting characters, 0 if not yet set
+	IP       string
+}
+
+// registering stops the login server and registration endpoint from creating the same name at once
+var registering sync.Mutex
+
+// dobFailures counts wrong passwords given when setting a dob, keyed by username
+var dobFailures = struct {
+	sync.Mutex
+	count map[string]int
+	last  map[string]int64
+}{count: make(map[string]int), last: make(map[string]int64)}
+
+func validName(name string) bool {
+	if len(name) < constant.AccountNameMinLength || len(name) > constant.AccountNameMaxLength {
+		return false
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validPassword(secret string) bool {
+	return len(secret) >= constant.AccountPasswordMinLength && len(secret) <= constant.AccountPasswordMaxLength
+}
+
+func validDob(dob int32) bool {
+	t, err := time.Parse("20060102", strconv.Itoa(int(dob)))
+
+	return err == nil && t.Before(time.Now())
+}
+
+// Validate the registration against the name and password rules
+func (r Registration) Validate() error {
+	if !validName(r.Username) {
+		return ErrInvalidName
+	}
+
+	if !validPassword(r.Password) {
+		return ErrInvalidPassword
+	}
+
+	if r.Gender > 1 {
+		return ErrInvalidGender
+	}
+
+	if r.Dob != 0 && !validDob(r.Dob) {
+		return ErrInvalidDob
+	}
+
+	return nil
+}
+
+// Register the account, returning its id, if the ip has not registered too many recently
+func Register(db *sql.DB, r Registration) (int32, error) {
+	if err := r.Validate(); err != nil {
+		return 0, err
+	}
+
+	registering.Lock()
+	defer registering.Unlock()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE registerIP=? AND registeredAt > ?",
+		r.IP, time.Now().Unix()-constant.AccountRegistrationWindow).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if count >= constant.AccountRegistrationsPerIP {
+		return 0, ErrTooManyRegistrations
+	}
+
+	return create(db, r)
+}
+
+// Create the account without any ip limit, returning its id
+func Create(db *sql.DB, r Registration) (int32, error) {
+	if err := r.Validate(); err != nil {
+		return 0, err
+	}
+
+	registering.Lock()
+	defer registering.Unlock()
+
+	return create(db, r)
+}
+
+func create(db *sql.DB, r Registration) (int32, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE username=?", r.Username).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		return 0, ErrNameTaken
+	}
+
+	hash, err := password.Hash(r.Password)
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := db.Exec("INSERT INTO accounts(username, password, gender, dob, registerIP, registeredAt) VALUES(?,?,?,?,?,?)",
+		r.Username, hash, r.Gender, r.Dob, r.IP, time.Now().Unix())
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+
+	return int32(id), err
+}
+
+// SetDob for an account that was registered without one, the password must match the account's
+func SetDob(db *sql.DB, username, secret string, dob int32) error {
+	if !validDob(dob) {
+		return ErrInvalidDob
+	}
+
+	now := time.Now().Unix()
+
+	dobFailures.Lock()
+	defer dobFailures.Unlock()
+
+	if now-dobFailures.last[username] > constant.LoginLockoutTime {
+		delete(dobFailures.count, username)
+		delete(dobFailures.last, username)
+	}
+
+	if dobFailures.count[username] >= constant.LoginMaxFailures {
+		return ErrTooManyAttempts
+	}
+
+	var hash string
+	var storedDob int32
+	err := db.QueryRow("SELECT password, dob FROM accounts WHERE username=?", username).Scan(&hash, &storedDob)
+
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if match, _ := password.Verify(secret, hash); err == sql.ErrNoRows || !match {
+		dobFailures.count[username]++
+		dobFailures.last[username] = now
+		return ErrWrongLogin
+	}
+
+	if storedDob != 0 {
+		return ErrDobSet
+	}
+
+	_, err = db.Exec("UPDATE accounts SET dob=? WHERE username=?", dob, username)
+
+	return err
+}
@@ -0,0 +1,97 @@
+package account
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Hucaru/Valhalla/server/ban"
+)
+
+// Handler accepts registrations posted as a form with username, password, gender and dob (yyyymmdd) fields
+// and lets accounts registered on first login set their dob by posting username, password and dob to /dob
+func Handler(db *sql.DB) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		gender, err := strconv.ParseUint(r.PostFormValue("gender"), 10, 8)
+
+		if err != nil {
+			http.Error(w, ErrInvalidGender.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dob, err := strconv.ParseInt(r.PostFormValue("dob"), 10, 32)
+
+		if err != nil || !validDob(int32(dob)) {
+			http.Error(w, ErrInvalidDob.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := Register(db, Registration{
+			Username: r.PostFormValue("username"),
+			Password: r.PostFormValue("password"),
+			Gender:   byte(gender),
+			Dob:      int32(dob),
+			IP:       ban.IPFromAddr(r.RemoteAddr),
+		})
+
+		switch err {
+		case nil:
+			log.Println("Registered account", id, "from", r.RemoteAddr)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("registered\n"))
+		case ErrInvalidName, ErrInvalidPassword, ErrInvalidGender, ErrInvalidDob:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case ErrNameTaken:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case ErrTooManyRegistrations:
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			log.Println("Unable to register account:", err)
+			http.Error(w, "unable to register account", http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/dob", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		dob, err := strconv.ParseInt(r.PostFormValue("dob"), 10, 32)
+
+		if err != nil {
+			http.Error(w, ErrInvalidDob.Error(), http.StatusBadRequest)
+			return
+		}
+
+		username := r.PostFormValue("username")
+		err = SetDob(db, username, r.PostFormValue("password"), int32(dob))
+
+		switch err {
+		case nil:
+			log.Println("Set dob for", username, "from", r.RemoteAddr)
+			w.Write([]byte("dob set\n"))
+		case ErrInvalidDob:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case ErrWrongLogin:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case ErrDobSet:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case ErrTooManyAttempts:
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		default:
+			log.Println("Unable to set dob for", username+":", err)
+			http.Error(w, "unable to set dob", http.StatusInternalServerError)
+		}
+	})
+
+	return mux
+}
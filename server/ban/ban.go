@@ -0,0 +1,124 @@
+// Package ban stores account and ip bans in the bans table
+package ban
+
+import (
+	"database/sql"
+	"net"
+	"time"
+)
+
+// Ban placed on an account and optionally the ip it was last seen on
+type Ban struct {
+	ID        int64
+	AccountID int32
+	IP        string
+	Reason    byte
+	GM        string
+	Start     time.Time
+	End       time.Time // zero for permanent bans
+}
+
+// Permanent returns true if the ban never ends
+func (b Ban) Permanent() bool {
+	return b.End.IsZero()
+}
+
+// IPFromAddr strips the port from a connection's remote address
+func IPFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
+
+// Create a ban for the account, a duration of zero is permanent
+func Create(db *sql.DB, accountID int32, ip string, reason byte, gm string, duration time.Duration) (Ban, error) {
+	b := Ban{AccountID: accountID, IP: ip, Reason: reason, GM: gm, Start: time.Now()}
+
+	var end sql.NullInt64
+
+	if duration > 0 {
+		b.End = b.Start.Add(duration)
+		end = sql.NullInt64{Int64: b.End.Unix(), Valid: true}
+	}
+
+	res, err := db.Exec("INSERT INTO bans(accountID, ip, reason, gmName, startTime, endTime) VALUES(?,?,?,?,?,?)",
+		b.AccountID, b.IP, b.Reason, b.GM, b.Start.Unix(), end)
+
+	if err != nil {
+		return b, err
+	}
+
+	b.ID, err = res.LastInsertId()
+
+	if err != nil {
+		return b, err
+	}
+
+	_, err = db.Exec("UPDATE accounts SET isBanned=? WHERE accountID=?", reason, accountID)
+
+	return b, err
+}
+
+// Active ban on the account or ip, the ban that ends last is returned if there are several
+func Active(db *sql.DB, accountID int32, ip string) (Ban, bool, error) {
+	var b Ban
+	var start int64
+	var end sql.NullInt64
+
+	err := db.QueryRow(`SELECT id, accountID, ip, reason, gmName, startTime, endTime FROM bans
+		WHERE active=1 AND (accountID=? OR (ip<>'' AND ip=?)) AND (endTime IS NULL OR endTime > ?)
+		ORDER BY endTime IS NULL DESC, endTime DESC LIMIT 1`, accountID, ip, time.Now().Unix()).
+		Scan(&b.ID, &b.AccountID, &b.IP, &b.Reason, &b.GM, &start, &end)
+
+	if err == sql.ErrNoRows {
+		return b, false, nil
+	} else if err != nil {
+		return b, false, err
+	}
+
+	b.Start = time.Unix(start, 0)
+
+	if end.Valid {
+		b.End = time.Unix(end.Int64, 0)
+	}
+
+	return b, true, nil
+}
+
+// Expire the account's temporary bans that have ended and clear the account's ban flag if any did. Accounts
+// flagged as banned without any rows in bans keep their flag.
+func Expire(db *sql.DB, accountID int32) (bool, error) {
+	res, err := db.Exec("UPDATE bans SET active=0 WHERE accountID=? AND active=1 AND endTime IS NOT NULL AND endTime <= ?",
+		accountID, time.Now().Unix())
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = db.Exec("UPDATE accounts SET isBanned=0 WHERE accountID=?", accountID)
+
+	return err == nil, err
+}
+
+// Lift all bans on the account
+func Lift(db *sql.DB, accountID int32) error {
+	_, err := db.Exec("UPDATE bans SET active=0 WHERE accountID=?", accountID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE accounts SET isBanned=0 WHERE accountID=?", accountID)
+
+	return err
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/cashshop"
+	"github.com/Hucaru/Valhalla/server/migration"
+)
+
+// CashShopServer state
+type CashShopServer struct {
+	worldName string
+	db        *sql.DB
+	dispatch  chan func()
+	world     mnet.Server
+	ip        []byte
+	port      int16
+	migrating []mnet.Client
+	players   players
+	storage   map[int32]cashshop.Storage // account id -> storage
+	tickets   migration.Tickets          // character id -> ticket to connect here
+	waiting   migration.Waiting          // character id -> connection that arrived before its ticket
+	channels  [20]channel
+}
+
+// Initialise the server
+func (server *CashShopServer) Initialise(work chan func(), dbuser, dbpassword, dbaddress, dbport, dbdatabase string) {
+	server.dispatch = work
+	server.storage = make(map[int32]cashshop.Storage)
+	server.tickets = make(migration.Tickets)
+	server.waiting = make(migration.Waiting)
+
+	var err error
+	server.db, err = sql.Open("mysql", dbuser+":"+dbpassword+"@tcp("+dbaddress+":"+dbport+")/"+dbdatabase)
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	err = server.db.Ping()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	log.Println("Connected to database")
+
+	_, err = server.db.Exec("UPDATE accounts SET isLogedIn=0 WHERE accountID IN (SELECT accountID FROM characters WHERE channelID=?)", constant.CashShopID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	_, err = server.db.Exec("UPDATE characters SET channelID=? WHERE channelID=?", -1, constant.CashShopID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	log.Println("Loged out any accounts still connected to the cash shop")
+}
+
+// RegisterWithWorld server
+func (server *CashShopServer) RegisterWithWorld(conn mnet.Server, ip []byte, port int16) {
+	server.world = conn
+	server.ip = ip
+	server.port = port
+
+	server.registerWithWorld()
+}
+
+func (server *CashShopServer) registerWithWorld() {
+	p := mpacket.CreateInternal(opcode.CashShopNew)
+	p.WriteBytes(server.ip)
+	p.WriteInt16(server.port)
+	server.world.Send(p)
+}
+
+// HandleServerPacket from world
+func (server *CashShopServer) HandleServerPacket(conn mnet.Server, reader mpacket.Reader) {
+	switch reader.ReadByte() {
+	case opcode.CashShopBad:
+		server.handleNewCashShopBad(conn, reader)
+	case opcode.CashShopOk:
+		server.handleNewCashShopOK(conn, reader)
+	case opcode.ChannelConnectionInfo:
+		server.handleChannelConnectionInfo(conn, reader)
+	case opcode.DisconnectAccount:
+		accountID := reader.ReadInt32()
+
+		for _, v := range server.players {
+			if v.AccountID() == accountID {
+				v.Conn().Close()
+			}
+		}
+	case opcode.MigrationTicket:
+		ticket := migration.Read(&reader)
+		server.tickets.Add(ticket)
+
+		if conn, ok := server.waiting.Release(ticket.CharacterID); ok {
+			server.connectCharacter(conn, ticket.CharacterID)
+		}
+	default:
+		log.Println("UNKNOWN SERVER PACKET:", reader)
+	}
+}
+
+func (server *CashShopServer) handleNewCashShopBad(conn mnet.Server, reader mpacket.Reader) {
+	log.Println("Rejected by world server at", conn)
+	timer := time.NewTimer(30 * time.Second)
+
+	<-timer.C
+
+	server.registerWithWorld()
+}
+
+func (server *CashShopServer) handleNewCashShopOK(conn mnet.Server, reader mpacket.Reader) {
+	server.worldName = reader.ReadString(reader.ReadInt16())
+	log.Println("Registered as cash shop on world", server.worldName)
+}
+
+func (server *CashShopServer) handleChannelConnectionInfo(conn mnet.Server, reader mpacket.Reader) {
+	total := reader.ReadByte()
+
+	for i := byte(0); i < total; i++ {
+		server.channels[i].ip = reader.ReadBytes(4)
+		server.channels[i].port = reader.ReadInt16()
+	}
+}
+
+// ClientDisconnected from server
+func (server *CashShopServer) ClientDisconnected(conn mnet.Client) {
+	server.waiting.Remove(conn)
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	err = plr.Save(server.db)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	_, err = server.db.Exec("UPDATE characters SET channelID=? WHERE id=?", -1, plr.ID())
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	server.players.removeFromConn(conn)
+	delete(server.storage, conn.GetAccountID())
+
+	index := -1
+
+	for i, v := range server.migrating {
+		if v == conn {
+			index = i
+		}
+	}
+
+	if index > -1 {
+		server.migrating = append(server.migrating[:index], server.migrating[index+1:]...)
+	} else {
+		_, err := server.db.Exec("UPDATE accounts SET isLogedIn=0 WHERE accountID=?", conn.GetAccountID())
+
+		if err != nil {
+			log.Println("Unable to complete logout for ", conn.GetAccountID())
+		}
+	}
+
+	conn.Cleanup()
+}
@@ -0,0 +1,85 @@
+package cashshop
+
+import (
+	"database/sql"
+
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+)
+
+// Gift waiting in an account's gift box
+type Gift struct {
+	dbID       int64
+	accountID  int32
+	sn         int32
+	itemID     int32
+	senderName string
+	message    string
+}
+
+// SendGift places a commodity into the gift box of the receiving account
+func SendGift(db *sql.DB, accountID int32, commodity nx.Commodity, senderName, message string) error {
+	_, err := db.Exec("INSERT INTO cashshop_gifts(accountID,sn,itemID,senderName,message) VALUES(?,?,?,?,?)",
+		accountID, commodity.SN, commodity.ItemID, senderName, message)
+
+	return err
+}
+
+// LoadGifts for an account
+func LoadGifts(db *sql.DB, accountID int32) ([]Gift, error) {
+	rows, err := db.Query("SELECT id,accountID,sn,itemID,senderName,message FROM cashshop_gifts WHERE accountID=?", accountID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	gifts := []Gift{}
+
+	for rows.Next() {
+		var gift Gift
+
+		err = rows.Scan(&gift.dbID, &gift.accountID, &gift.sn, &gift.itemID, &gift.senderName, &gift.message)
+
+		if err != nil {
+			return nil, err
+		}
+
+		gifts = append(gifts, gift)
+	}
+
+	return gifts, nil
+}
+
+// Claim the gift, moving it into the account storage
+func (g Gift) Claim(db *sql.DB) (Item, error) {
+	commodity, err := nx.GetCommodity(g.sn)
+
+	if err != nil {
+		return Item{}, err
+	}
+
+	item := CreateFromCommodity(g.accountID, commodity, g.senderName)
+
+	err = item.Save(db)
+
+	if err != nil {
+		return Item{}, err
+	}
+
+	_, err = db.Exec("DELETE FROM cashshop_gifts WHERE id=?", g.dbID)
+
+	return item, err
+}
+
+// Bytes used when displaying the gift box
+func (g Gift) Bytes() []byte {
+	p := mpacket.NewPacket()
+	p.WriteInt64(g.dbID)
+	p.WriteInt32(g.itemID)
+	p.WritePaddedString(g.senderName, 13)
+	p.WritePaddedString(g.message, 73)
+
+	return p
+}
@@ -0,0 +1,200 @@
+package cashshop
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/item"
+)
+
+// Item held in an account's cash shop storage, it is not tied to a character until taken out
+type Item struct {
+	dbID       int64
+	accountID  int32
+	itemID     int32
+	amount     int16
+	sn         int32
+	expireTime int64
+	gifterName string
+
+	// pet stats are kept here while the pet is in storage as its pets row goes with the inventory item
+	petName      string
+	petLevel     byte
+	petCloseness int16
+	petFullness  byte
+}
+
+// CreateFromCommodity creates a storage item for an account from a commodity listing
+func CreateFromCommodity(accountID int32, commodity nx.Commodity, gifterName string) Item {
+	stored := Item{
+		accountID:  accountID,
+		itemID:     commodity.ItemID,
+		amount:     commodity.Count,
+		sn:         commodity.SN,
+		gifterName: gifterName,
+	}
+
+	if stored.amount < 1 {
+		stored.amount = 1
+	}
+
+	if commodity.Period > 0 {
+		stored.expireTime = time.Now().Add(time.Duration(commodity.Period) * 24 * time.Hour).Unix()
+	}
+
+	return stored
+}
+
+// CreateFromInventory creates a storage item for an item that is being moved out of a character inventory
+func CreateFromInventory(accountID int32, invItem item.Data) Item {
+	stored := Item{accountID: accountID, itemID: invItem.ID(), amount: invItem.Amount(), expireTime: invItem.ExpireTime()}
+
+	if invItem.Pet() {
+		stored.petName = invItem.PetName()
+		stored.petLevel = invItem.PetLevel()
+		stored.petCloseness = invItem.PetCloseness()
+		stored.petFullness = invItem.PetFullness()
+	}
+
+	return stored
+}
+
+// InventoryItem creates the inventory item for a stored item that is being taken out
+func (v Item) InventoryItem() (item.Data, error) {
+	newItem, err := item.CreateFromID(v.itemID, v.amount)
+
+	if err != nil {
+		return newItem, err
+	}
+
+	newItem.SetExpireTime(v.expireTime)
+
+	// pets bought from the shop have no stats stored and keep the new pet defaults
+	if newItem.Pet() && v.petLevel > 0 {
+		newItem.SetPetName(v.petName)
+		newItem.SetPetLevel(v.petLevel)
+		newItem.SetPetCloseness(v.petCloseness)
+		newItem.SetPetFullness(v.petFullness)
+	}
+
+	return newItem, nil
+}
+
+// DbID of the storage entry, the client uses this as the cash serial number
+func (v Item) DbID() int64 { return v.dbID }
+
+// ItemID of the stored item
+func (v Item) ItemID() int32 { return v.itemID }
+
+// Amount of the stored item
+func (v Item) Amount() int16 { return v.amount }
+
+// SN of the commodity the item was purchased from
+func (v Item) SN() int32 { return v.sn }
+
+// ExpireTime of the stored item in unix seconds, zero if permanent
+func (v Item) ExpireTime() int64 { return v.expireTime }
+
+// Save storage item to database
+func (v *Item) Save(db *sql.DB) error {
+	if v.dbID != 0 {
+		_, err := db.Exec("UPDATE cashshop_storage SET amount=?, expireTime=? WHERE id=?", v.amount, v.expireTime, v.dbID)
+		return err
+	}
+
+	res, err := db.Exec("INSERT INTO cashshop_storage(accountID,itemID,amount,sn,expireTime,gifterName,petName,petLevel,petCloseness,petFullness) VALUES(?,?,?,?,?,?,?,?,?,?)",
+		v.accountID, v.itemID, v.amount, v.sn, v.expireTime, v.gifterName, v.petName, v.petLevel, v.petCloseness, v.petFullness)
+
+	if err != nil {
+		return err
+	}
+
+	v.dbID, err = res.LastInsertId()
+
+	return err
+}
+
+// Delete storage item from database
+func (v Item) Delete(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM cashshop_storage WHERE id=?", v.dbID)
+	return err
+}
+
+// Restore a deleted storage item under its original id, the client still refers to it by that id
+func (v Item) Restore(db *sql.DB) error {
+	_, err := db.Exec("INSERT INTO cashshop_storage(id,accountID,itemID,amount,sn,expireTime,gifterName,petName,petLevel,petCloseness,petFullness) VALUES(?,?,?,?,?,?,?,?,?,?,?)",
+		v.dbID, v.accountID, v.itemID, v.amount, v.sn, v.expireTime, v.gifterName, v.petName, v.petLevel, v.petCloseness, v.petFullness)
+
+	return err
+}
+
+// Bytes used when displaying the item in the storage window
+func (v Item) Bytes(characterID int32) []byte {
+	p := mpacket.NewPacket()
+	p.WriteInt64(v.dbID)
+	p.WriteInt32(v.accountID)
+	p.WriteInt32(characterID)
+	p.WriteInt32(v.itemID)
+	p.WriteInt32(v.sn)
+	p.WriteInt16(v.amount)
+	p.WritePaddedString(v.gifterName, 13)
+	p.WriteInt64(item.Filetime(v.expireTime))
+
+	return p
+}
+
+// Storage of items belonging to an account
+type Storage []Item
+
+// LoadStorage for an account
+func LoadStorage(db *sql.DB, accountID int32) (Storage, error) {
+	rows, err := db.Query("SELECT id,accountID,itemID,amount,sn,expireTime,gifterName,petName,petLevel,petCloseness,petFullness FROM cashshop_storage WHERE accountID=?", accountID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	storage := Storage{}
+
+	for rows.Next() {
+		var stored Item
+
+		err = rows.Scan(&stored.dbID, &stored.accountID, &stored.itemID, &stored.amount, &stored.sn, &stored.expireTime, &stored.gifterName,
+			&stored.petName, &stored.petLevel, &stored.petCloseness, &stored.petFullness)
+
+		if err != nil {
+			return nil, err
+		}
+
+		storage = append(storage, stored)
+	}
+
+	return storage, nil
+}
+
+// Get item from storage via its database id
+func (s Storage) Get(dbID int64) (Item, error) {
+	for _, v := range s {
+		if v.dbID == dbID {
+			return v, nil
+		}
+	}
+
+	return Item{}, fmt.Errorf("Could not find storage item %d", dbID)
+}
+
+// Remove item from storage via its database id
+func (s *Storage) Remove(dbID int64) {
+	for i, v := range *s {
+		if v.dbID == dbID {
+			(*s)[i] = (*s)[len(*s)-1]
+			*s = (*s)[:len(*s)-1]
+			return
+		}
+	}
+}
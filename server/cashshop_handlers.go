@@ -0,0 +1,439 @@
+package server
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/ban"
+	"github.com/Hucaru/Valhalla/server/cashshop"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/migration"
+	"github.com/Hucaru/Valhalla/server/player"
+)
+
+// HandleClientPacket data
+func (server *CashShopServer) HandleClientPacket(conn mnet.Client, reader mpacket.Reader) {
+	switch reader.ReadByte() {
+	case opcode.RecvPing:
+	case opcode.RecvChannelPlayerLoad:
+		server.playerConnect(conn, reader)
+	case opcode.RecvChannelUserPortal:
+		server.playerLeave(conn, reader)
+	case opcode.RecvCashShopQueryCash:
+		server.playerQueryCash(conn, reader)
+	case opcode.RecvCashShopOperation:
+		server.playerOperation(conn, reader)
+	default:
+		log.Println("UNKNOWN CASH SHOP CLIENT PACKET:", reader)
+	}
+}
+
+func (server *CashShopServer) playerConnect(conn mnet.Client, reader mpacket.Reader) {
+	server.connectCharacter(conn, reader.ReadInt32())
+}
+
+// connectCharacter once its migration ticket is here, a client that arrives before its ticket is held until it does
+func (server *CashShopServer) connectCharacter(conn mnet.Client, charID int32) {
+	var migrationID int8
+	var accountID int32
+	err := server.db.QueryRow("SELECT migrationID, accountID FROM characters WHERE id=?", charID).Scan(&migrationID, &accountID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if migrationID != constant.CashShopID {
+		return
+	}
+
+	if _, ok := server.tickets[charID]; !ok {
+		if !server.waiting.Hold(charID, conn) {
+			log.Println("Refused character", charID, "from", conn, "as another connection is waiting for its ticket")
+		}
+
+		return
+	}
+
+	ticket, ok := server.tickets.Redeem(charID, constant.CashShopID, ban.IPFromAddr(conn.String()))
+
+	if !ok || ticket.AccountID != accountID {
+		log.Println("Refused character", charID, "from", conn, "without a valid migration ticket")
+		return
+	}
+
+	conn.SetAccountID(accountID)
+
+	var adminLevel int
+	err = server.db.QueryRow("SELECT adminLevel FROM accounts WHERE accountID=?", accountID).Scan(&adminLevel)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	conn.SetAdminLevel(adminLevel)
+
+	_, err = server.db.Exec("UPDATE characters SET migrationID=?, channelID=? WHERE id=?", -1, constant.CashShopID, charID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	plr := player.LoadFromID(server.db, charID, conn)
+	server.players = append(server.players, &plr)
+
+	storage, err := cashshop.LoadStorage(server.db, accountID)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	pending, err := cashshop.LoadGifts(server.db, accountID)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	// gifts that do not fit in storage stay in the gift box until there is room
+	gifts := []cashshop.Gift{}
+
+	for _, gift := range pending {
+		if len(storage) >= constant.CashShopStorageSize {
+			break
+		}
+
+		claimed, err := gift.Claim(server.db)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		storage = append(storage, claimed)
+		gifts = append(gifts, gift)
+	}
+
+	server.storage[accountID] = storage
+
+	conn.Send(packetCashShopSet(plr))
+	conn.Send(packetCashShopStorage(storage, plr.ID(), constant.CashShopStorageSize))
+
+	if len(gifts) > 0 {
+		conn.Send(packetCashShopGifts(gifts))
+	}
+
+	server.sendBalance(conn)
+}
+
+func (server *CashShopServer) playerLeave(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	var channelID int8
+	err = server.db.QueryRow("SELECT previousChannelID FROM characters WHERE id=?", plr.ID()).Scan(&channelID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// If the channel the player came from has gone down, send them to the first available one
+	if channelID < 0 || int(channelID) >= len(server.channels) || server.channels[channelID].port == 0 {
+		channelID = -1
+
+		for i, v := range server.channels {
+			if v.port != 0 {
+				channelID = int8(i)
+				break
+			}
+		}
+
+		if channelID == -1 {
+			conn.Send(message.PacketCannotChangeChannel())
+			return
+		}
+	}
+
+	ticket := migration.New(conn.GetAccountID(), plr.ID(), byte(channelID), ban.IPFromAddr(conn.String()))
+
+	server.world.Send(ticket.Packet())
+
+	_, err = server.db.Exec("UPDATE characters SET migrationID=? WHERE id=?", channelID, plr.ID())
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server.migrating = append(server.migrating, conn)
+	conn.Send(packetChangeChannel(server.channels[channelID].ip, server.channels[channelID].port))
+}
+
+func (server *CashShopServer) playerQueryCash(conn mnet.Client, reader mpacket.Reader) {
+	server.sendBalance(conn)
+}
+
+func (server *CashShopServer) sendBalance(conn mnet.Client) {
+	var nxCash, maplePoints int32
+	err := server.db.QueryRow("SELECT nx, maplePoints FROM accounts WHERE accountID=?", conn.GetAccountID()).Scan(&nxCash, &maplePoints)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	conn.Send(packetCashShopBalance(nxCash, maplePoints))
+}
+
+// spendCurrency atomically removes the amount from the account balance, failing if it cannot be afforded
+func (server *CashShopServer) spendCurrency(accountID int32, currency int32, amount int32) error {
+	var column string
+
+	switch currency {
+	case constant.CashShopCurrencyNX:
+		column = "nx"
+	case constant.CashShopCurrencyMaplePoints:
+		column = "maplePoints"
+	default:
+		return fmt.Errorf("Unknown cash shop currency %d", currency)
+	}
+
+	res, err := server.db.Exec("UPDATE accounts SET "+column+"="+column+"-? WHERE accountID=? AND "+column+">=?", amount, accountID, amount)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("Account %d cannot afford %d of currency %d", accountID, amount, currency)
+	}
+
+	return nil
+}
+
+func (server *CashShopServer) refundCurrency(accountID int32, currency int32, amount int32) {
+	column := "nx"
+
+	if currency == constant.CashShopCurrencyMaplePoints {
+		column = "maplePoints"
+	}
+
+	_, err := server.db.Exec("UPDATE accounts SET "+column+"="+column+"+? WHERE accountID=?", amount, accountID)
+
+	if err != nil {
+		log.Println("Unable to refund", amount, "to account", accountID, err)
+	}
+}
+
+func (server *CashShopServer) playerOperation(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	switch reader.ReadByte() {
+	case constant.CashShopBuyItem:
+		currency := reader.ReadInt32()
+		sn := reader.ReadInt32()
+		server.playerBuyItem(plr, currency, sn)
+	case constant.CashShopGiftItem:
+		sn := reader.ReadInt32()
+		receiver := reader.ReadString(reader.ReadInt16())
+		msg := reader.ReadString(reader.ReadInt16())
+		server.playerGiftItem(plr, sn, receiver, msg)
+	case constant.CashShopTakeOutItem:
+		server.playerTakeOutItem(plr, reader.ReadInt64())
+	case constant.CashShopPutInItem:
+		invID := reader.ReadByte()
+		slotID := reader.ReadInt16()
+		server.playerPutInItem(plr, invID, slotID)
+	default:
+		log.Println("Unknown cash shop operation:", reader)
+	}
+
+	server.sendBalance(conn)
+}
+
+func (server *CashShopServer) playerBuyItem(plr *player.Data, currency int32, sn int32) {
+	commodity, err := nx.GetCommodity(sn)
+
+	if err != nil || !commodity.OnSale {
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	if commodity.Gender != 2 && commodity.Gender != plr.Gender() {
+		plr.Send(packetCashShopError(3))
+		return
+	}
+
+	accountID := plr.AccountID()
+	storage := server.storage[accountID]
+
+	if len(storage) >= constant.CashShopStorageSize {
+		plr.Send(packetCashShopError(2))
+		return
+	}
+
+	if err := server.spendCurrency(accountID, currency, commodity.Price); err != nil {
+		log.Println(err)
+		plr.Send(packetCashShopError(1))
+		return
+	}
+
+	newItem := cashshop.CreateFromCommodity(accountID, commodity, "")
+
+	if err := newItem.Save(server.db); err != nil {
+		log.Println(err)
+		server.refundCurrency(accountID, currency, commodity.Price)
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	server.storage[accountID] = append(storage, newItem)
+	plr.Send(packetCashShopBuyDone(newItem, plr.ID()))
+}
+
+func (server *CashShopServer) playerGiftItem(plr *player.Data, sn int32, receiver, msg string) {
+	commodity, err := nx.GetCommodity(sn)
+
+	if err != nil || !commodity.OnSale {
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	var receiverAccountID int32
+	var receiverGender byte
+	err = server.db.QueryRow("SELECT accountID, gender FROM characters WHERE name=? AND worldID=? AND deletedAt IS NULL", receiver, plr.WorldID()).Scan(&receiverAccountID, &receiverGender)
+
+	if err != nil {
+		plr.Send(packetCashShopError(4))
+		return
+	}
+
+	if receiverAccountID == plr.AccountID() {
+		plr.Send(packetCashShopError(5))
+		return
+	}
+
+	if commodity.Gender != 2 && commodity.Gender != receiverGender {
+		plr.Send(packetCashShopError(3))
+		return
+	}
+
+	// Gifts can only be bought with nx
+	if err := server.spendCurrency(plr.AccountID(), constant.CashShopCurrencyNX, commodity.Price); err != nil {
+		log.Println(err)
+		plr.Send(packetCashShopError(1))
+		return
+	}
+
+	if err := cashshop.SendGift(server.db, receiverAccountID, commodity, plr.Name(), msg); err != nil {
+		log.Println(err)
+		server.refundCurrency(plr.AccountID(), constant.CashShopCurrencyNX, commodity.Price)
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	plr.Send(packetCashShopGiftDone(receiver, commodity.ItemID, commodity.Price))
+}
+
+func (server *CashShopServer) playerTakeOutItem(plr *player.Data, dbID int64) {
+	accountID := plr.AccountID()
+	storage := server.storage[accountID]
+	stored, err := storage.Get(dbID)
+
+	if err != nil {
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	newItem, err := stored.InventoryItem()
+
+	if err != nil {
+		log.Println(err)
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	// remove the storage row before the item exists in the inventory so a failure cannot duplicate it
+	if err := stored.Delete(server.db); err != nil {
+		log.Println(err)
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	if err := plr.GiveItem(newItem, server.db); err != nil {
+		if err := stored.Restore(server.db); err != nil {
+			log.Println(err)
+		}
+
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	storage.Remove(dbID)
+	server.storage[accountID] = storage
+	plr.Send(packetCashShopTakeOutDone(dbID))
+}
+
+func (server *CashShopServer) playerPutInItem(plr *player.Data, invID byte, slotID int16) {
+	accountID := plr.AccountID()
+	storage := server.storage[accountID]
+
+	if len(storage) >= constant.CashShopStorageSize {
+		plr.Send(packetCashShopError(2))
+		return
+	}
+
+	// negative slots are equipped items
+	if slotID < 1 {
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	invItem, err := plr.GetItem(invID, slotID)
+
+	if err != nil || !invItem.Cash() {
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	stored := cashshop.CreateFromInventory(accountID, invItem)
+
+	if err := stored.Save(server.db); err != nil {
+		log.Println(err)
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	// the stored row only counts once the inventory row is gone
+	if err := invItem.Delete(server.db); err != nil {
+		log.Println(err)
+
+		if err := stored.Delete(server.db); err != nil {
+			log.Println(err)
+		}
+
+		plr.Send(packetCashShopError(0))
+		return
+	}
+
+	if _, err := plr.RemoveItem(invID, slotID, server.db); err != nil {
+		log.Println(err)
+	}
+
+	server.storage[accountID] = append(storage, stored)
+	plr.Send(packetCashShopPutInDone(stored, plr.ID()))
+}
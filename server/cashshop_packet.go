@@ -0,0 +1,125 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/cashshop"
+	"github.com/Hucaru/Valhalla/server/player"
+)
+
+func packetCashShopSet(plr player.Data) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelSetCashShop)
+	p.WriteBytes(plr.InfoBytes())
+	p.WriteBool(true) // cash shop enabled
+
+	commodities := []nx.Commodity{}
+
+	for _, v := range nx.GetCommodities() {
+		if v.OnSale {
+			commodities = append(commodities, v)
+		}
+	}
+
+	sort.Slice(commodities, func(i, j int) bool {
+		if commodities[i].Priority == commodities[j].Priority {
+			return commodities[i].SN < commodities[j].SN
+		}
+
+		return commodities[i].Priority > commodities[j].Priority
+	})
+
+	p.WriteInt16(int16(len(commodities)))
+
+	for _, v := range commodities {
+		p.WriteInt32(v.SN)
+		p.WriteInt32(v.ItemID)
+		p.WriteInt16(v.Count)
+		p.WriteInt32(v.Price)
+		p.WriteInt16(v.Period)
+		p.WriteInt32(v.Priority)
+		p.WriteByte(v.Gender)
+		p.WriteBool(v.OnSale)
+	}
+
+	return p
+}
+
+func packetCashShopBalance(nxCash, maplePoints int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopQueryCashResult)
+	p.WriteInt32(nxCash)
+	p.WriteInt32(maplePoints)
+
+	return p
+}
+
+func packetCashShopStorage(storage cashshop.Storage, characterID int32, storageSize int16) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x1C) // load storage
+	p.WriteInt16(int16(len(storage)))
+
+	for _, v := range storage {
+		p.WriteBytes(v.Bytes(characterID))
+	}
+
+	p.WriteInt16(storageSize)
+
+	return p
+}
+
+func packetCashShopGifts(gifts []cashshop.Gift) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x1E) // load gift box
+	p.WriteInt16(int16(len(gifts)))
+
+	for _, v := range gifts {
+		p.WriteBytes(v.Bytes())
+	}
+
+	return p
+}
+
+func packetCashShopBuyDone(item cashshop.Item, characterID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x22)
+	p.WriteBytes(item.Bytes(characterID))
+
+	return p
+}
+
+func packetCashShopGiftDone(receiver string, itemID int32, price int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x24)
+	p.WriteString(receiver)
+	p.WriteInt32(itemID)
+	p.WriteInt32(price)
+
+	return p
+}
+
+func packetCashShopTakeOutDone(dbID int64) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x2C)
+	p.WriteInt64(dbID)
+
+	return p
+}
+
+func packetCashShopPutInDone(item cashshop.Item, characterID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x2E)
+	p.WriteBytes(item.Bytes(characterID))
+
+	return p
+}
+
+// reason: 0 - unknown error, 1 - not enough cash, 2 - storage full, 3 - wrong gender, 4 - unknown receiver, 5 - cannot gift to own account
+func packetCashShopError(reason byte) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendCashShopOperation)
+	p.WriteByte(0x23)
+	p.WriteByte(reason)
+
+	return p
+}
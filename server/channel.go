@@ -14,9 +14,11 @@ import (
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/ban"
 	"github.com/Hucaru/Valhalla/server/field"
 	"github.com/Hucaru/Valhalla/server/message"
 	"github.com/Hucaru/Valhalla/server/metrics"
+	"github.com/Hucaru/Valhalla/server/migration"
 	"github.com/Hucaru/Valhalla/server/player"
 )
 
@@ -88,8 +90,19 @@ type ChannelServer struct {
 	migrating []mnet.Client
 	players   players
 	channels  [20]channel
+	cashShop  channel
 	fields    map[int32]*field.Field
 	header    string
+
+	attackAction string // what to do with attacks that fail validation
+	rates        rates  // set by the world server
+
+	mysticDoors map[int32][2]mysticDoorLocation // owner id -> door pair
+	events      map[int32]*partyQuest           // leader id -> running event
+	tickets     migration.Tickets               // character id -> ticket to connect here
+	waiting     migration.Waiting               // character id -> connection that arrived before its ticket
+
+	transportTick time.Time
 }
 
 // Initialise the server
@@ -112,6 +125,11 @@ func (server *ChannelServer) Initialise(work chan func(), dbuser, dbpassword, db
 	log.Println("Connected to database")
 
 	server.fields = make(map[int32]*field.Field)
+	server.mysticDoors = make(map[int32][2]mysticDoorLocation)
+	server.events = make(map[int32]*partyQuest)
+	server.tickets = make(migration.Tickets)
+	server.waiting = make(migration.Waiting)
+	server.rates = rates{exp: 1, drop: 1, mesos: 1}
 
 	for fieldID, nxMap := range nx.GetMaps() {
 
@@ -119,6 +137,8 @@ func (server *ChannelServer) Initialise(work chan func(), dbuser, dbpassword, db
 			ID:       fieldID,
 			Data:     nxMap,
 			Dispatch: server.dispatch,
+			Expel:    server.expelPlayer,
+			ExpRate:  server.expRate,
 		}
 
 		server.fields[fieldID].CalculateFieldLimits()
@@ -169,6 +189,62 @@ func (server *ChannelServer) Initialise(work chan func(), dbuser, dbpassword, db
 	prometheus.MustRegister(metrics.Gauges["player_count"])
 
 	log.Println("Started serving metrics on :" + strconv.Itoa(metrics.Port))
+
+	server.startTimer()
+}
+
+// startTimer ticks every second to update timed state e.g. pet hunger, running events and transports
+func (server *ChannelServer) startTimer() {
+	ticker := time.NewTicker(time.Second)
+
+	go func() {
+		for t := range ticker.C {
+			server.dispatch <- func() {
+				server.playerUpdate(t)
+				server.eventUpdate(t)
+				server.transportUpdate(t)
+			}
+		}
+	}()
+}
+
+func (server *ChannelServer) playerUpdate(t time.Time) {
+	for _, plr := range server.players {
+		plr.UpdatePet(t, server.db)
+		plr.RemoveExpiredItems(t, server.db)
+	}
+}
+
+// expelPlayer from their current field to the given map
+func (server *ChannelServer) expelPlayer(playerID, mapID int32) {
+	plr, err := server.players.getFromID(playerID)
+
+	if err != nil {
+		return
+	}
+
+	dstField, ok := server.fields[mapID]
+
+	if !ok {
+		return
+	}
+
+	dstInst, err := dstField.GetInstance(0)
+
+	if err != nil {
+		return
+	}
+
+	portal, err := dstInst.GetRandomSpawnPortal()
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err := server.warpPlayer(plr, dstField, portal); err != nil {
+		log.Println(err)
+	}
 }
 
 // SendCountdownToPlayers - Send a countdown to players that appears as a clock
@@ -216,6 +292,20 @@ func (server *ChannelServer) HandleServerPacket(conn mnet.Server, reader mpacket
 		server.handleNewChannelOK(conn, reader)
 	case opcode.ChannelConnectionInfo:
 		server.handleChannelConnectionInfo(conn, reader)
+	case opcode.CashShopInfo:
+		server.handleCashShopInfo(conn, reader)
+	case opcode.DisconnectAccount:
+		server.disconnectAccount(reader.ReadInt32())
+	case opcode.MigrationTicket:
+		ticket := migration.Read(&reader)
+		server.tickets.Add(ticket)
+
+		if conn, ok := server.waiting.Release(ticket.CharacterID); ok {
+			server.connectCharacter(conn, ticket.CharacterID)
+		}
+	case opcode.WorldRates:
+		server.rates.serialisePacket(&reader)
+		log.Printf("World rates set to exp %.2fx, drop %.2fx, mesos %.2fx", server.rates.exp, server.rates.drop, server.rates.mesos)
 	default:
 		log.Println("UNKNOWN SERVER PACKET:", reader)
 	}
@@ -245,8 +335,33 @@ func (server *ChannelServer) handleChannelConnectionInfo(conn mnet.Server, reade
 	}
 }
 
+func (server *ChannelServer) disconnectAccount(accountID int32) {
+	for _, v := range server.players {
+		if v.AccountID() == accountID {
+			v.Conn().Close()
+		}
+	}
+}
+
+func (server *ChannelServer) expRate() float32 {
+	return server.rates.exp
+}
+
+// sendMigrationTicket to the world server so that target will accept the client
+func (server *ChannelServer) sendMigrationTicket(conn mnet.Client, characterID int32, target byte) {
+	ticket := migration.New(conn.GetAccountID(), characterID, target, ban.IPFromAddr(conn.String()))
+	server.world.Send(ticket.Packet())
+}
+
+func (server *ChannelServer) handleCashShopInfo(conn mnet.Server, reader mpacket.Reader) {
+	server.cashShop.ip = reader.ReadBytes(4)
+	server.cashShop.port = reader.ReadInt16()
+}
+
 // ClientDisconnected from server
 func (server *ChannelServer) ClientDisconnected(conn mnet.Client) {
+	server.waiting.Remove(conn)
+
 	plr, err := server.players.getFromConn(conn)
 
 	if err != nil {
@@ -259,6 +374,9 @@ func (server *ChannelServer) ClientDisconnected(conn mnet.Client) {
 		return
 	}
 
+	server.removeMysticDoor(plr.ID())
+	plr.RemoveLogoutItems(server.db)
+
 	inst, err := field.GetInstance(plr.InstanceID())
 	err = inst.RemovePlayer(plr)
 
@@ -303,6 +421,17 @@ func (server *ChannelServer) ClientDisconnected(conn mnet.Client) {
 	metrics.Gauges["player_count"].With(prometheus.Labels{"channel": strconv.Itoa(int(server.id)), "world": server.worldName}).Dec()
 }
 
+// SetAttackViolationAction taken when a player's attack fails validation, one of cap, drop or disconnect
+func (server *ChannelServer) SetAttackViolationAction(action string) {
+	switch action {
+	case AttackActionCap, AttackActionDrop, AttackActionDisconnect:
+		server.attackAction = action
+	default:
+		log.Println("Unknown attack violation action", action, "defaulting to", AttackActionDrop)
+		server.attackAction = AttackActionDrop
+	}
+}
+
 // SetScrollingHeaderMessage that appears at the top of game window
 func (server *ChannelServer) SetScrollingHeaderMessage(msg string) {
 	server.header = msg
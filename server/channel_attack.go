@@ -1,12 +1,21 @@
 package server
 
 import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/field"
 	"github.com/Hucaru/Valhalla/server/message"
 	"github.com/Hucaru/Valhalla/server/player"
 	"github.com/Hucaru/Valhalla/server/pos"
+	"github.com/Hucaru/gonx"
 )
 
 func (server ChannelServer) playerMeleeSkill(conn mnet.Client, reader mpacket.Reader) {
@@ -36,6 +45,10 @@ func (server ChannelServer) playerMeleeSkill(conn mnet.Client, reader mpacket.Re
 		return
 	}
 
+	if !server.validateAttack(plr, inst, &data) {
+		return
+	}
+
 	// if player in party extract
 
 	packetSkillMelee := func(char player.Data, ad attackData) mpacket.Packet {
@@ -116,13 +129,6 @@ func getAttackInfo(reader mpacket.Reader, player player.Data, attackType int) (a
 		return data, false
 	}
 
-	// speed hack check
-	if false && (reader.Time-player.LastAttackPacketTime() < 350) {
-		return data, false
-	}
-
-	player.SetLastAttackPacketTime(reader.Time)
-
 	if attackType != attackSummon {
 		tByte := reader.ReadByte()
 		skillID := reader.ReadInt32()
@@ -158,10 +164,9 @@ func getAttackInfo(reader mpacket.Reader, player player.Data, attackType int) (a
 	reader.Skip(4) //checksum info?
 
 	if attackType == attackRanged {
-		projectileSlot := reader.ReadInt16() // star/arrow slot
-		if projectileSlot == 0 {
-			// if soul arrow is not set check for hacks
-		} else {
+		projectileSlot := reader.ReadInt16() // star/arrow slot, 0 when soul arrow is active
+
+		if projectileSlot != 0 {
 			data.projectileID = -1
 
 			for _, item := range player.Use() {
@@ -169,6 +174,10 @@ func getAttackInfo(reader mpacket.Reader, player player.Data, attackType int) (a
 					data.projectileID = item.ID()
 				}
 			}
+
+			if data.projectileID == -1 {
+				return data, false
+			}
 		}
 		reader.ReadByte() // ?
 		reader.ReadByte() // ?
@@ -222,3 +231,193 @@ func getAttackInfo(reader mpacket.Reader, player player.Data, attackType int) (a
 
 	return data, true
 }
+
+// Actions that can be taken when an attack fails validation
+const (
+	AttackActionCap        = "cap"        // damage, targets and hits are capped to what is plausible
+	AttackActionDrop       = "drop"       // the attack is ignored
+	AttackActionDisconnect = "disconnect" // the player is disconnected
+)
+
+// attackLimits a player's attack must stay within
+type attackLimits struct {
+	maxDamage   int32
+	mobCount    byte
+	attackCount byte
+	lt, rb      pos.Data // range box relative to the player facing left
+}
+
+// calculateAttackLimits for a melee attack. Ranged and magic attacks are not handled by the server yet,
+// limits for them need adding when their handlers are.
+func calculateAttackLimits(plr *player.Data, data attackData) attackLimits {
+	limits := attackLimits{mobCount: 1, attackCount: 1}
+
+	var skill nx.PlayerSkill
+	hasSkill := false
+
+	if data.skillID != 0 {
+		if levels, err := nx.GetPlayerSkill(data.skillID); err == nil && data.skillLevel > 0 && int(data.skillLevel) <= len(levels) {
+			skill = levels[data.skillLevel-1]
+			hasSkill = true
+		}
+	}
+
+	if hasSkill {
+		if skill.MobCount > 0 {
+			limits.mobCount = byte(skill.MobCount)
+		}
+
+		if skill.AttackCount > 0 {
+			limits.attackCount = byte(skill.AttackCount)
+		}
+
+		if skill.BulletCount > 0 && byte(skill.BulletCount) > limits.attackCount {
+			limits.attackCount = byte(skill.BulletCount)
+		}
+	}
+
+	if hasSkill && (skill.Lt != gonx.Vector{} || skill.Rb != gonx.Vector{}) {
+		limits.lt = pos.New(int16(skill.Lt.X), int16(skill.Lt.Y), 0)
+		limits.rb = pos.New(int16(skill.Rb.X), int16(skill.Rb.Y), 0)
+	} else {
+		limits.lt = pos.New(-constant.AttackMeleeRangeX, -constant.AttackMeleeRangeY, 0)
+		limits.rb = pos.New(0, constant.AttackMeleeRangeY/2, 0)
+	}
+
+	stats := plr.TotalStats()
+	weaponType := byte(0)
+
+	if weapon, ok := plr.Weapon(); ok {
+		weaponType = weapon.WeaponType()
+	}
+
+	watk := stats.Watk
+
+	if watk < 1 {
+		watk = 1
+	}
+
+	damage := weaponStatDamage(weaponType, stats) * float64(watk) / 100
+
+	if hasSkill && skill.Damage > 0 {
+		damage *= float64(skill.Damage) / 100
+	}
+
+	damage *= constant.AttackDamageLeeway
+
+	if damage > constant.AttackMaxDamage {
+		damage = constant.AttackMaxDamage
+	}
+
+	limits.maxDamage = int32(damage) + 1
+
+	return limits
+}
+
+// weaponStatDamage is the stat part of the max damage formula for the weapon type
+func weaponStatDamage(weaponType byte, stats player.Stats) float64 {
+	str, dex, luk := float64(stats.Str), float64(stats.Dex), float64(stats.Luk)
+
+	switch weaponType {
+	case 1: // Sword1H
+		return str*4.0 + dex
+	case 2, 3, 5, 6: // Axe1H, Blunt1H, Wand, Staff
+		return str*4.4 + dex
+	case 4, 14: // Dagger, Claw
+		return luk*3.6 + str + dex
+	case 7: // Sword2H
+		return str*4.6 + dex
+	case 8, 9, 15: // Axe2H, Blunt2H, Knuckle
+		return str*4.8 + dex
+	case 10, 11: // Spear, PoleArm
+		return str*5.0 + dex
+	case 12: // Bow
+		return dex*3.4 + str
+	case 13, 16: // Crossbow, Gun
+		return dex*3.6 + str
+	}
+
+	return str*4.2 + dex // bare handed
+}
+
+func (limits attackLimits) inRange(plrPos, mobPos pos.Data, facesLeft bool) bool {
+	dx := mobPos.X() - plrPos.X()
+	dy := mobPos.Y() - plrPos.Y()
+
+	if !facesLeft {
+		dx = -dx
+	}
+
+	return dx >= limits.lt.X()-constant.AttackRangeTolerance && dx <= limits.rb.X()+constant.AttackRangeTolerance &&
+		dy >= limits.lt.Y()-constant.AttackRangeTolerance && dy <= limits.rb.Y()+constant.AttackRangeTolerance
+}
+
+// validateAttack against the attack speed, target and hit counts, range and damage the player could plausibly do.
+// The attack is capped in place and false is returned if it should not be applied.
+func (server ChannelServer) validateAttack(plr *player.Data, inst *field.Instance, data *attackData) bool {
+	violations := []string{}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	tooFast := now-plr.LastAttackPacketTime() < constant.AttackMinInterval
+	plr.SetLastAttackPacketTime(now)
+
+	if tooFast {
+		violations = append(violations, "attack speed")
+	}
+
+	limits := calculateAttackLimits(plr, *data)
+
+	if data.targets > limits.mobCount {
+		violations = append(violations, fmt.Sprintf("hit %d mobs, limit %d", data.targets, limits.mobCount))
+		data.attackInfo = data.attackInfo[:limits.mobCount]
+		data.targets = limits.mobCount
+	}
+
+	if !data.isMesoExplosion && data.hits > limits.attackCount {
+		violations = append(violations, fmt.Sprintf("hit %d times, limit %d", data.hits, limits.attackCount))
+
+		for i := range data.attackInfo {
+			data.attackInfo[i].damages = data.attackInfo[i].damages[:limits.attackCount]
+		}
+
+		data.hits = limits.attackCount
+	}
+
+	inRange := []attackInfo{}
+
+	for _, attack := range data.attackInfo {
+		mob, err := inst.LifePool().GetMobFromSpawnID(attack.spawnID)
+
+		if err == nil && !limits.inRange(plr.Pos(), mob.Pos(), data.facesLeft) {
+			violations = append(violations, fmt.Sprintf("mob %d out of range", attack.spawnID))
+			continue
+		}
+
+		for i, dmg := range attack.damages {
+			if dmg > limits.maxDamage {
+				violations = append(violations, fmt.Sprintf("damage %d, limit %d", dmg, limits.maxDamage))
+				attack.damages[i] = limits.maxDamage
+			}
+		}
+
+		inRange = append(inRange, attack)
+	}
+
+	data.attackInfo = inRange
+	data.targets = byte(len(inRange))
+
+	if len(violations) == 0 {
+		return true
+	}
+
+	log.Println("Attack from", plr.Name(), "skill", data.skillID, "failed validation:", strings.Join(violations, ", "))
+
+	switch server.attackAction {
+	case AttackActionCap:
+		return !tooFast && data.targets > 0
+	case AttackActionDisconnect:
+		plr.Conn().Close()
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/ban"
+)
+
+var banReasons = map[string]byte{
+	"hacking":     constant.BanReasonHacking,
+	"botting":     constant.BanReasonBotting,
+	"advertising": constant.BanReasonAdvertising,
+	"harassment":  constant.BanReasonHarassment,
+	"profanity":   constant.BanReasonProfanity,
+	"scamming":    constant.BanReasonScamming,
+	"misconduct":  constant.BanReasonMisconduct,
+	"transaction": constant.BanReasonIllegalTransaction,
+	"charging":    constant.BanReasonIllegalCharging,
+	"temporary":   constant.BanReasonTemporary,
+	"impersonate": constant.BanReasonImpersonatingGM,
+	"programs":    constant.BanReasonIllegalPrograms,
+}
+
+// convertBanReason from a name or number, unknown reasons are treated as hacking
+func convertBanReason(reason string) byte {
+	if v, ok := banReasons[reason]; ok {
+		return v
+	}
+
+	if v, err := strconv.Atoi(reason); err == nil && v > 0 && v <= constant.BanReasonIllegalPrograms {
+		return byte(v)
+	}
+
+	return constant.BanReasonHacking
+}
+
+func (server *ChannelServer) accountIDFromName(name string) (int32, error) {
+	var accountID int32
+	err := server.db.QueryRow("SELECT accountID FROM characters WHERE name=?", name).Scan(&accountID)
+
+	if err != nil {
+		return 0, fmt.Errorf("Unable to find character %s", name)
+	}
+
+	return accountID, nil
+}
+
+// banCharacter's account, a duration of zero is permanent. The account is disconnected from every channel.
+func (server *ChannelServer) banCharacter(gmName, name string, reason byte, duration time.Duration) (ban.Ban, error) {
+	accountID, err := server.accountIDFromName(name)
+
+	if err != nil {
+		return ban.Ban{}, err
+	}
+
+	ip := ""
+
+	if plr, err := server.players.getFromName(name); err == nil {
+		ip = ban.IPFromAddr(plr.Conn().String())
+	}
+
+	b, err := ban.Create(server.db, accountID, ip, reason, gmName, duration)
+
+	if err != nil {
+		log.Println("Unable to ban account", accountID, err)
+		return b, fmt.Errorf("Unable to ban %s", name)
+	}
+
+	log.Println(gmName, "banned", name, "account", accountID, "reason", reason, "for", duration)
+
+	server.disconnectAccount(accountID)
+
+	p := mpacket.CreateInternal(opcode.DisconnectAccount)
+	p.WriteInt32(accountID)
+	server.world.Send(p)
+
+	return b, nil
+}
+
+func (server *ChannelServer) unbanCharacter(gmName, name string) error {
+	accountID, err := server.accountIDFromName(name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ban.Lift(server.db, accountID); err != nil {
+		log.Println("Unable to unban account", accountID, err)
+		return fmt.Errorf("Unable to unban %s", name)
+	}
+
+	log.Println(gmName, "unbanned", name, "account", accountID)
+
+	return nil
+}
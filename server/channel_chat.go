@@ -7,13 +7,16 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/nx"
-	"github.com/Hucaru/Valhalla/server/field/droppool"
 	"github.com/Hucaru/Valhalla/server/item"
 	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/script/event"
 )
 
 func (server *ChannelServer) chatSendAll(conn mnet.Client, reader mpacket.Reader) {
@@ -119,6 +122,79 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 		}
 	case "wheader": // sends to world server to propagate to all channels
 
+	case "worldmessage":
+		p := mpacket.CreateInternal(opcode.WorldMessage)
+		p.WriteString(strings.Join(command[1:], " "))
+		server.world.Send(p)
+
+		conn.Send(message.PacketMessageNotice("World message sent to world server"))
+	case "ribbon":
+		if len(command) != 2 {
+			conn.Send(message.PacketMessageRedText("Command structure is /ribbon <0 normal, 1 event, 2 new, 3 hot>"))
+			return
+		}
+
+		ribbon, err := strconv.Atoi(command[1])
+
+		if err != nil || ribbon < 0 || ribbon > 3 {
+			conn.Send(message.PacketMessageRedText("Ribbon must be 0 normal, 1 event, 2 new or 3 hot"))
+			return
+		}
+
+		p := mpacket.CreateInternal(opcode.WorldRibbon)
+		p.WriteByte(byte(ribbon))
+		server.world.Send(p)
+
+		conn.Send(message.PacketMessageNotice("World ribbon sent to world server"))
+	case "rates":
+		if len(command) == 1 {
+			conn.Send(message.PacketMessageNotice(fmt.Sprintf("Rates are exp %.2fx, drop %.2fx, mesos %.2fx", server.rates.exp, server.rates.drop, server.rates.mesos)))
+			return
+		}
+
+		var r rates
+		var minutes int64
+
+		if len(command) == 2 && command[1] == "reset" {
+			r = rates{}
+		} else if len(command) == 4 || len(command) == 5 {
+			values := make([]float32, 3)
+
+			for i := range values {
+				v, err := strconv.ParseFloat(command[i+1], 32)
+
+				if err != nil || v <= 0 {
+					conn.Send(message.PacketMessageRedText("Rates must be positive numbers"))
+					return
+				}
+
+				values[i] = float32(v)
+			}
+
+			r = rates{exp: values[0], drop: values[1], mesos: values[2]}
+
+			if len(command) == 5 {
+				m, err := strconv.ParseInt(command[4], 10, 64)
+
+				if err != nil || m < 1 {
+					conn.Send(message.PacketMessageRedText("Duration must be a positive number of minutes"))
+					return
+				}
+
+				minutes = m
+			}
+		} else {
+			conn.Send(message.PacketMessageRedText("Command structure is /rates [<exp> <drop> <mesos> [minutes] | reset]"))
+			return
+		}
+
+		p := mpacket.CreateInternal(opcode.ChangeRates)
+		r.write(&p)
+		p.WriteInt64(minutes * 60)
+		server.world.Send(p)
+
+		conn.Send(message.PacketMessageNotice("Rates change sent to world server"))
+
 	case "kill":
 		player, err := server.players.getFromConn(conn)
 
@@ -245,6 +321,127 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 		}
 
 		conn.Send(message.PacketMessageNotice("Deleted"))
+	case "suspicion":
+		if len(command) == 2 {
+			plr, err := server.players.getFromName(command[1])
+
+			if err != nil {
+				conn.Send(message.PacketMessageRedText(err.Error()))
+				return
+			}
+
+			conn.Send(message.PacketMessageNotice(plr.Name() + " suspicion: " + strconv.Itoa(plr.Suspicion())))
+			return
+		}
+
+		found := false
+
+		for _, v := range server.players {
+			if v.Suspicion() > 0 {
+				conn.Send(message.PacketMessageNotice(v.Name() + " suspicion: " + strconv.Itoa(v.Suspicion())))
+				found = true
+			}
+		}
+
+		if !found {
+			conn.Send(message.PacketMessageNotice("No suspicious players"))
+		}
+	case "ban", "tempban":
+		temp := command[0] == "tempban"
+
+		if (!temp && len(command) < 2) || (temp && len(command) < 3) {
+			conn.Send(message.PacketMessageRedText("Command structure is /ban <name> [reason] or /tempban <name> <hours> [reason]"))
+			return
+		}
+
+		gm, err := server.players.getFromConn(conn)
+
+		if err != nil {
+			return
+		}
+
+		var duration time.Duration
+		reasonIndex := 2
+
+		if temp {
+			hours, err := strconv.Atoi(command[2])
+
+			if err != nil || hours < 1 {
+				conn.Send(message.PacketMessageRedText("Ban length must be a positive number of hours"))
+				return
+			}
+
+			duration = time.Duration(hours) * time.Hour
+			reasonIndex = 3
+		}
+
+		reason := byte(constant.BanReasonHacking)
+
+		if len(command) > reasonIndex {
+			reason = convertBanReason(command[reasonIndex])
+		}
+
+		if _, err := server.accountIDFromName(command[1]); err != nil {
+			conn.Send(message.PacketMessageGmBan(false))
+			return
+		}
+
+		if _, err := server.banCharacter(gm.Name(), command[1], reason, duration); err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		conn.Send(message.PacketMessageNotice(command[1] + " has been banned"))
+	case "unban":
+		if len(command) != 2 {
+			conn.Send(message.PacketMessageRedText("Command structure is /unban <name>"))
+			return
+		}
+
+		gm, err := server.players.getFromConn(conn)
+
+		if err != nil {
+			return
+		}
+
+		if err := server.unbanCharacter(gm.Name(), command[1]); err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		conn.Send(message.PacketMessageNotice(command[1] + " has been unbanned"))
+	case "event":
+		if len(command) != 2 {
+			conn.Send(message.PacketMessageRedText("Command structure is /event <name>"))
+			return
+		}
+
+		def, err := event.Load(command[1])
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		plr, err := server.players.getFromConn(conn)
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		// Everyone in the same instance as the gm takes part, with the gm as leader
+		plrs := players{plr}
+
+		for _, v := range server.players {
+			if v != plr && v.MapID() == plr.MapID() && v.InstanceID() == plr.InstanceID() {
+				plrs = append(plrs, v)
+			}
+		}
+
+		if err := server.startEvent(def, plrs); err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+		}
 	case "hp":
 		player, err := server.players.getFromConn(conn)
 
@@ -390,6 +587,28 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 		}
 
 		player.SetJob(jobID)
+	case "advance":
+		if len(command) < 2 {
+			conn.Send(message.PacketMessageRedText("Command structure is /advance <job name or id>"))
+			return
+		}
+
+		jobID := convertJobNameToID(command[1])
+
+		if val, err := strconv.Atoi(command[1]); err == nil {
+			jobID = int16(val)
+		}
+
+		player, err := server.players.getFromConn(conn)
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		if err := player.AdvanceJob(jobID); err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+		}
 	case "item":
 		var itemID int32
 		var amount int16 = 1
@@ -789,6 +1008,31 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 
 		// inst.CreatePublicMysticDoor(dstField, plr.Pos(), time.Now().Add(time.Second*60).Unix())
 	case "drop":
+		var itemID int32 = 1332020
+		var amount int16 = 1
+
+		if len(command) > 1 {
+			val, err := strconv.Atoi(command[1])
+
+			if err != nil {
+				conn.Send(message.PacketMessageRedText(err.Error()))
+				return
+			}
+
+			itemID = int32(val)
+		}
+
+		if len(command) > 2 {
+			val, err := strconv.Atoi(command[2])
+
+			if err != nil {
+				conn.Send(message.PacketMessageRedText(err.Error()))
+				return
+			}
+
+			amount = int16(val)
+		}
+
 		plr, err := server.players.getFromConn(conn)
 
 		if err != nil {
@@ -796,8 +1040,41 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 			return
 		}
 
-		plr.Send(droppool.PacketShowDrop(1, plr.Pos(), plr.Pos(), false, 1587842888000))
+		field, ok := server.fields[plr.MapID()]
+
+		if !ok {
+			conn.Send(message.PacketMessageRedText("Could not find field ID"))
+			return
+		}
+
+		inst, err := field.GetInstance(plr.InstanceID())
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		newItem, err := item.CreateFromID(itemID, amount)
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		inst.DropPool().CreatePlayerDrop(plr.ID(), newItem, plr.Pos())
 	case "dropr":
+		if len(command) != 2 {
+			conn.Send(message.PacketMessageRedText("Usage: /dropr <drop id>"))
+			return
+		}
+
+		dropID, err := strconv.Atoi(command[1])
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
 		plr, err := server.players.getFromConn(conn)
 
 		if err != nil {
@@ -805,7 +1082,23 @@ func (server *ChannelServer) gmCommand(conn mnet.Client, msg string) {
 			return
 		}
 
-		plr.Send(droppool.PacketRemoveDrop(false, 1))
+		field, ok := server.fields[plr.MapID()]
+
+		if !ok {
+			conn.Send(message.PacketMessageRedText("Could not find field ID"))
+			return
+		}
+
+		inst, err := field.GetInstance(plr.InstanceID())
+
+		if err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+			return
+		}
+
+		if err := inst.DropPool().RemoveDrop(int32(dropID)); err != nil {
+			conn.Send(message.PacketMessageRedText(err.Error()))
+		}
 	default:
 		conn.Send(message.PacketMessageRedText("Unkown gm command " + command[0]))
 	}
@@ -0,0 +1,477 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/server/field"
+	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/player"
+	"github.com/Hucaru/Valhalla/server/script/event"
+	"github.com/Hucaru/Valhalla/server/script/npc"
+)
+
+// partyQuest is a running event, its stages are played in private instances of the stage maps
+type partyQuest struct {
+	def       event.Definition
+	leaderID  int32
+	playerIDs []int32
+	stage     int
+	instances map[int32]*field.Instance // map id -> private instance
+
+	startTime time.Time
+	stageTime time.Time
+
+	kills     map[int32]int // mob id -> kills in current stage
+	drops     map[int32]int // item id -> amount dropped in current stage
+	npcTalked bool
+}
+
+// MobKilled in one of the event instances
+func (pq *partyQuest) MobKilled(mobID int32) {
+	pq.kills[mobID]++
+}
+
+// ItemDropped in one of the event instances
+func (pq *partyQuest) ItemDropped(itemID int32, amount int16) {
+	pq.drops[itemID] += int(amount)
+}
+
+func (pq partyQuest) hasPlayer(id int32) bool {
+	for _, v := range pq.playerIDs {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pq partyQuest) ownsInstance(inst *field.Instance) bool {
+	for _, v := range pq.instances {
+		if v == inst {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pq partyQuest) currentStage() event.Stage {
+	return pq.def.Stages[pq.stage]
+}
+
+func (pq partyQuest) deadline() time.Time {
+	end := pq.startTime.Add(time.Duration(pq.def.TimeLimit) * time.Second)
+
+	if limit := pq.currentStage().TimeLimit; limit > 0 {
+		if stageEnd := pq.stageTime.Add(time.Duration(limit) * time.Second); pq.def.TimeLimit == 0 || stageEnd.Before(end) {
+			return stageEnd
+		}
+	}
+
+	return end
+}
+
+func (pq partyQuest) stageCleared() bool {
+	cond := pq.currentStage().Clear
+
+	if cond.Empty() {
+		return false
+	}
+
+	if cond.MobCount > 0 {
+		kills := pq.kills[cond.MobID]
+
+		if cond.MobID == 0 {
+			kills = 0
+
+			for _, v := range pq.kills {
+				kills += v
+			}
+		}
+
+		if kills < cond.MobCount {
+			return false
+		}
+	}
+
+	if cond.ItemCount > 0 && pq.drops[cond.ItemID] < cond.ItemCount {
+		return false
+	}
+
+	if cond.NpcID != 0 && !pq.npcTalked {
+		return false
+	}
+
+	return true
+}
+
+// instanceAccessible to the player, instances that belong to an event are private to the players taking part in it
+func (server ChannelServer) instanceAccessible(plr *player.Data, inst *field.Instance) bool {
+	if pq, ok := inst.EventListener().(*partyQuest); ok && !pq.hasPlayer(plr.ID()) {
+		return false
+	}
+
+	return true
+}
+
+func (server *ChannelServer) getPlayerEvent(plrID int32) *partyQuest {
+	for _, pq := range server.events {
+		if pq.hasPlayer(plrID) {
+			return pq
+		}
+	}
+
+	return nil
+}
+
+// startEvent for the given players, the first player is the leader
+func (server *ChannelServer) startEvent(def event.Definition, plrs []*player.Data) error {
+	if len(plrs) == 0 || len(def.Stages) == 0 {
+		return fmt.Errorf("Event %s has nothing to start", def.Name)
+	}
+
+	if len(plrs) < def.MinPlayers || (def.MaxPlayers > 0 && len(plrs) > def.MaxPlayers) {
+		return fmt.Errorf("Event %s needs between %d and %d players", def.Name, def.MinPlayers, def.MaxPlayers)
+	}
+
+	for _, plr := range plrs {
+		if plr.Level() < def.MinLevel || (def.MaxLevel > 0 && plr.Level() > def.MaxLevel) {
+			return fmt.Errorf("%s does not meet the level requirement of %s", plr.Name(), def.Name)
+		}
+
+		if server.getPlayerEvent(plr.ID()) != nil {
+			return fmt.Errorf("%s is already in an event", plr.Name())
+		}
+	}
+
+	if _, ok := server.fields[def.ExitMap]; !ok {
+		return fmt.Errorf("Event %s has invalid exit map %d", def.Name, def.ExitMap)
+	}
+
+	for _, stage := range def.Stages {
+		if _, ok := server.fields[stage.MapID]; !ok {
+			return fmt.Errorf("Event %s has invalid stage map %d", def.Name, stage.MapID)
+		}
+	}
+
+	now := time.Now()
+
+	pq := &partyQuest{
+		def:       def,
+		leaderID:  plrs[0].ID(),
+		instances: make(map[int32]*field.Instance),
+		startTime: now,
+		stageTime: now,
+		kills:     make(map[int32]int),
+		drops:     make(map[int32]int),
+	}
+
+	for _, plr := range plrs {
+		pq.playerIDs = append(pq.playerIDs, plr.ID())
+	}
+
+	for _, stage := range def.Stages {
+		if _, ok := pq.instances[stage.MapID]; ok {
+			continue
+		}
+
+		dstField := server.fields[stage.MapID]
+		inst, err := dstField.GetInstance(dstField.CreateInstance())
+
+		if err != nil {
+			server.deleteEventInstances(pq)
+			return err
+		}
+
+		inst.SetEventListener(pq)
+		pq.instances[stage.MapID] = inst
+	}
+
+	server.events[pq.leaderID] = pq
+	server.moveEventToStage(pq, plrs)
+
+	return nil
+}
+
+func (server *ChannelServer) eventPlayers(pq *partyQuest) []*player.Data {
+	plrs := []*player.Data{}
+
+	for _, id := range pq.playerIDs {
+		if plr, err := server.players.getFromID(id); err == nil {
+			plrs = append(plrs, plr)
+		}
+	}
+
+	return plrs
+}
+
+func (server *ChannelServer) moveEventToStage(pq *partyQuest, plrs []*player.Data) {
+	stage := pq.currentStage()
+	dstField := server.fields[stage.MapID]
+	dstInst := pq.instances[stage.MapID]
+
+	remaining := int32(pq.deadline().Sub(pq.stageTime).Seconds())
+
+	for _, plr := range plrs {
+		if plr.MapID() != stage.MapID || plr.InstanceID() != dstInst.ID() {
+			portal, err := dstInst.GetRandomSpawnPortal()
+
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			if err := server.warpPlayerToInstance(plr, dstField, dstInst, portal); err != nil {
+				log.Println(err)
+				continue
+			}
+		}
+
+		if remaining > 0 {
+			plr.Send(message.PacketShowCountdown(remaining))
+		}
+	}
+}
+
+func (server *ChannelServer) clearEventStage(pq *partyQuest) {
+	reward := pq.currentStage().Reward
+	plrs := server.eventPlayers(pq)
+
+	for _, plr := range plrs {
+		if reward.Exp > 0 {
+			plr.GiveEXP(reward.Exp, false, false)
+		}
+
+		if reward.Mesos > 0 {
+			plr.GiveMesos(int32(float64(reward.Mesos) * float64(server.rates.mesos)))
+		}
+
+		for _, v := range reward.Items {
+			for i := server.rates.dropCount(); i > 0; i-- {
+				newItem, err := item.CreateFromID(v.ID, v.Amount)
+
+				if err != nil {
+					log.Println(err)
+					break
+				}
+
+				if err := plr.GiveItem(newItem, server.db); err != nil {
+					plr.Send(message.PacketMessageRedText("Your inventory is full, a reward could not be given"))
+					break
+				}
+			}
+		}
+
+		plr.Send(message.PacketMessageNotice("Stage clear"))
+	}
+
+	pq.stage++
+
+	if pq.stage >= len(pq.def.Stages) {
+		server.endEvent(pq, true)
+		return
+	}
+
+	pq.stageTime = time.Now()
+	pq.kills = make(map[int32]int)
+	pq.drops = make(map[int32]int)
+	pq.npcTalked = false
+
+	server.moveEventToStage(pq, plrs)
+}
+
+// endEvent by sending everyone still in it to the exit map and removing the private instances
+func (server *ChannelServer) endEvent(pq *partyQuest, completed bool) {
+	exitField := server.fields[pq.def.ExitMap]
+	exitInst, err := exitField.GetInstance(0)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, plr := range server.eventPlayers(pq) {
+		plr.Send(message.PacketHideCountdown())
+
+		if completed {
+			plr.Send(message.PacketMessageNotice("You have completed " + pq.def.Name))
+		} else {
+			plr.Send(message.PacketMessageNotice("You have failed " + pq.def.Name))
+		}
+
+		if !pq.ownsInstance(server.playerInstance(plr)) {
+			continue
+		}
+
+		portal, err := exitInst.GetRandomSpawnPortal()
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if err := server.warpPlayerToInstance(plr, exitField, exitInst, portal); err != nil {
+			log.Println(err)
+		}
+	}
+
+	delete(server.events, pq.leaderID)
+	server.deleteEventInstances(pq)
+}
+
+func (server *ChannelServer) deleteEventInstances(pq *partyQuest) {
+	for mapID, inst := range pq.instances {
+		inst.SetEventListener(nil)
+
+		if err := server.fields[mapID].DeleteInstance(inst.ID()); err != nil {
+			log.Println("Unable to delete event instance in map", mapID, err)
+		}
+	}
+}
+
+func (server *ChannelServer) playerInstance(plr *player.Data) *field.Instance {
+	srcField, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return nil
+	}
+
+	inst, err := srcField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return nil
+	}
+
+	return inst
+}
+
+func (server *ChannelServer) eventUpdate(t time.Time) {
+	for _, pq := range server.events {
+		// Players that have left the event instances e.g. logged out or used a portal out are no longer in it
+		ids := []int32{}
+
+		for _, id := range pq.playerIDs {
+			plr, err := server.players.getFromID(id)
+
+			if err != nil {
+				continue
+			}
+
+			if !pq.ownsInstance(server.playerInstance(plr)) {
+				plr.Send(message.PacketHideCountdown())
+				continue
+			}
+
+			ids = append(ids, id)
+		}
+
+		pq.playerIDs = ids
+
+		if len(pq.playerIDs) == 0 {
+			server.endEvent(pq, false)
+			continue
+		}
+
+		if !pq.hasPlayer(pq.leaderID) {
+			server.passEventLeader(pq)
+		}
+
+		if t.After(pq.deadline()) {
+			// Running out of time in a bonus stage is how it ends
+			if pq.currentStage().Clear.Empty() {
+				server.clearEventStage(pq)
+			} else {
+				server.endEvent(pq, false)
+			}
+
+			continue
+		}
+
+		if pq.stageCleared() {
+			server.clearEventStage(pq)
+		}
+	}
+}
+
+// passEventLeader to the next player still in the event so npc stage clears can still be done
+func (server *ChannelServer) passEventLeader(pq *partyQuest) {
+	delete(server.events, pq.leaderID)
+	pq.leaderID = pq.playerIDs[0]
+	server.events[pq.leaderID] = pq
+
+	leader, err := server.players.getFromID(pq.leaderID)
+
+	if err != nil {
+		return
+	}
+
+	for _, plr := range server.eventPlayers(pq) {
+		plr.Send(message.PacketMessageNotice("The leader has left, " + leader.Name() + " is now the leader of " + pq.def.Name))
+	}
+}
+
+// eventGroup that enters an event with the player talking to the npc. Without a party system the group is everyone
+// standing near them in the same instance who is not already in an event, the talking player is the leader.
+func (server *ChannelServer) eventGroup(leader *player.Data, inst *field.Instance, def event.Definition) []*player.Data {
+	plrs := []*player.Data{leader}
+
+	for _, v := range server.players {
+		if def.MaxPlayers > 0 && len(plrs) >= def.MaxPlayers {
+			break
+		}
+
+		if v == leader || v.MapID() != leader.MapID() || v.InstanceID() != inst.ID() ||
+			!v.CheckPos(leader.Pos(), constant.EventGatherRange, constant.EventGatherRange) || server.getPlayerEvent(v.ID()) != nil {
+			continue
+		}
+
+		plrs = append(plrs, v)
+	}
+
+	return plrs
+}
+
+// eventNpcTalk handles npcs that start an event or clear an event stage, returns true if the npc was an event npc
+func (server *ChannelServer) eventNpcTalk(plr *player.Data, inst *field.Instance, npcID int32) bool {
+	if pq := server.getPlayerEvent(plr.ID()); pq != nil {
+		if !pq.ownsInstance(inst) || pq.currentStage().Clear.NpcID != npcID {
+			return false
+		}
+
+		if plr.ID() != pq.leaderID {
+			plr.Send(npc.PacketChatBackNext(npcID, "Please have your leader talk to me.", false, false))
+			return true
+		}
+
+		pq.npcTalked = true
+
+		if !pq.stageCleared() {
+			pq.npcTalked = false
+			plr.Send(npc.PacketChatBackNext(npcID, "You have not yet completed what this stage asks of you.", false, false))
+			return true
+		}
+
+		server.clearEventStage(pq)
+
+		return true
+	}
+
+	for _, def := range event.LoadAll() {
+		if def.NpcID != npcID {
+			continue
+		}
+
+		if err := server.startEvent(def, server.eventGroup(plr, inst, def)); err != nil {
+			plr.Send(npc.PacketChatBackNext(npcID, err.Error(), false, false))
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"log"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+)
+
+// Results of giving fame the client knows how to show
+const (
+	fameResultOk          = 0
+	fameResultInvalidName = 1
+	fameResultLowLevel    = 2
+	fameResultToday       = 3
+	fameResultThisMonth   = 4
+	fameResultReceived    = 5
+)
+
+func (server ChannelServer) playerGiveFame(conn mnet.Client, reader mpacket.Reader) {
+	targetID := reader.ReadInt32()
+	up := reader.ReadBool()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	target, err := server.players.getFromID(targetID)
+
+	if err != nil || target.ID() == plr.ID() || target.MapID() != plr.MapID() || target.InstanceID() != plr.InstanceID() {
+		conn.Send(packetFameError(fameResultInvalidName))
+		return
+	}
+
+	if plr.Level() < constant.FameMinLevel {
+		conn.Send(packetFameError(fameResultLowLevel))
+		return
+	}
+
+	var given int
+	err = server.db.QueryRow("SELECT COUNT(*) FROM fame_log WHERE fromID=? AND time > NOW() - INTERVAL 1 DAY", plr.ID()).Scan(&given)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if given >= constant.FameDailyLimit {
+		conn.Send(packetFameError(fameResultToday))
+		return
+	}
+
+	err = server.db.QueryRow("SELECT COUNT(*) FROM fame_log WHERE fromID=? AND toID=? AND time > NOW() - INTERVAL ? DAY",
+		plr.ID(), target.ID(), constant.FameTargetPeriod).Scan(&given)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if given > 0 {
+		conn.Send(packetFameError(fameResultThisMonth))
+		return
+	}
+
+	amount := int16(-1)
+
+	if up {
+		amount = 1
+	}
+
+	_, err = server.db.Exec("INSERT INTO fame_log(fromID, toID) VALUES(?,?)", plr.ID(), target.ID())
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	target.SetFame(target.Fame() + amount)
+
+	_, err = server.db.Exec("UPDATE characters SET fame=? WHERE id=?", target.Fame(), target.ID())
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	conn.Send(packetFameGiven(target.Name(), up, target.Fame()))
+	target.Send(packetFameReceived(plr.Name(), up))
+}
+
+func packetFameGiven(targetName string, up bool, newFame int16) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelFameResponse)
+	p.WriteByte(fameResultOk)
+	p.WriteString(targetName)
+	p.WriteBool(up)
+	p.WriteInt16(newFame)
+	p.WriteInt16(0)
+
+	return p
+}
+
+func packetFameReceived(giverName string, up bool) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelFameResponse)
+	p.WriteByte(fameResultReceived)
+	p.WriteString(giverName)
+	p.WriteBool(up)
+
+	return p
+}
+
+func packetFameError(result byte) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelFameResponse)
+	p.WriteByte(result)
+
+	return p
+}
@@ -19,6 +19,7 @@ func (server *ChannelServer) HandleClientPacket(conn mnet.Client, reader mpacket
 	case opcode.RecvChannelUserPortal:
 		server.playerUsePortal(conn, reader)
 	case opcode.RecvChannelEnterCashShop:
+		server.playerEnterCashShop(conn, reader)
 	case opcode.RecvChannelPlayerMovement:
 		server.playerMovement(conn, reader)
 	case opcode.RecvChannelPlayerStand:
@@ -55,7 +56,9 @@ func (server *ChannelServer) HandleClientPacket(conn mnet.Client, reader mpacket
 	case opcode.RecvChannelAddSkillPoint:
 		server.playerAddSkillPoint(conn, reader)
 	case opcode.RecvChannelSpecialSkill:
-		// server.playerSpecialSkill(conn, reader)
+		server.playerSpecialSkill(conn, reader)
+	case opcode.RecvChannelGiveFame:
+		server.playerGiveFame(conn, reader)
 	case opcode.RecvChannelCharacterInfo:
 		server.playerRequestAvatarInfoWindow(conn, reader)
 	case opcode.RecvChannelLieDetectorResult:
@@ -65,6 +68,24 @@ func (server *ChannelServer) HandleClientPacket(conn mnet.Client, reader mpacket
 	case opcode.RecvChannelAddBuddy:
 	case opcode.RecvChannelUseMysticDoor:
 		server.playerUseMysticDoor(conn, reader)
+	case opcode.RecvChannelScriptedPortal:
+		server.playerUseScriptedPortal(conn, reader)
+	case opcode.RecvChannelSpawnPet:
+		server.petSpawn(conn, reader)
+	case opcode.RecvChannelPetMovement:
+		server.petMovement(conn, reader)
+	case opcode.RecvChannelPetChat:
+		server.petChat(conn, reader)
+	case opcode.RecvChannelPetCommand:
+		server.petCommand(conn, reader)
+	case opcode.RecvChannelPetLoot:
+		server.petLoot(conn, reader)
+	case opcode.RecvChannelPetFood:
+		server.petFood(conn, reader)
+	case opcode.RecvChannelPetNameChange:
+		server.petNameChange(conn, reader)
+	case opcode.RecvChannelDropPickUp:
+		server.playerPickupDrop(conn, reader)
 	case opcode.RecvChannelMobControl:
 		server.mobControl(conn, reader)
 	case opcode.RecvChannelNpcMovement:
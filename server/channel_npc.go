@@ -58,6 +58,10 @@ func (server *ChannelServer) npcChatStart(conn mnet.Client, reader mpacket.Reade
 		return
 	}
 
+	if server.eventNpcTalk(plr, inst, npcData.ID()) {
+		return
+	}
+
 	conn.Send(npc.PacketChatYesNo(npcData.ID(), "#e#h ##n the NPC #bchat #dsystem #gis #r#enot #k#nimplemented"))
 }
 
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/movement"
+)
+
+func (server ChannelServer) petSpawn(conn mnet.Client, reader mpacket.Reader) {
+	slotID := reader.ReadInt16()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if err := plr.SpawnPet(slotID, server.db); err != nil {
+		log.Println(err)
+	}
+}
+
+func (server ChannelServer) petMovement(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	moveData, finalData := movement.ParseMovement(reader)
+	moveBytes := movement.GenerateMovementBytes(moveData)
+
+	plr.MovePet(moveBytes, finalData)
+}
+
+func (server ChannelServer) petChat(conn mnet.Client, reader mpacket.Reader) {
+	chatType := reader.ReadByte()
+	action := reader.ReadByte()
+	msg := reader.ReadString(reader.ReadInt16())
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	plr.PetChat(chatType, action, msg)
+}
+
+func (server ChannelServer) petCommand(conn mnet.Client, reader mpacket.Reader) {
+	commandID := reader.ReadByte()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if err := plr.PetCommand(commandID, server.db); err != nil {
+		log.Println(err)
+	}
+}
+
+func (server ChannelServer) petFood(conn mnet.Client, reader mpacket.Reader) {
+	slotID := reader.ReadInt16()
+	itemID := reader.ReadInt32()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if field, ok := server.fields[plr.MapID()]; ok && field.Limited(constant.FieldLimitConsumeItem) {
+		conn.Send(message.PacketMessageRedText("Items cannot be used here"))
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	if err := plr.FeedPet(slotID, itemID, server.db); err != nil {
+		log.Println(err)
+	}
+}
+
+func (server ChannelServer) petNameChange(conn mnet.Client, reader mpacket.Reader) {
+	slotID := reader.ReadInt16()
+	name := reader.ReadString(reader.ReadInt16())
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if err := plr.NamePet(slotID, name, server.db); err != nil {
+		log.Println(err)
+	}
+}
+
+func (server ChannelServer) petLoot(conn mnet.Client, reader mpacket.Reader) {
+	reader.Skip(4) // pet position
+	dropID := reader.ReadInt32()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	field, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	inst, err := field.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	pool := inst.DropPool()
+	drop, err := pool.GetDrop(dropID)
+
+	if err != nil {
+		return
+	}
+
+	if !drop.CanLoot(plr.ID(), time.Now()) || !plr.PetCanLoot(drop.Pos()) {
+		return
+	}
+
+	if err := server.lootDrop(plr, drop); err != nil {
+		return
+	}
+
+	pool.PetLootDrop(dropID, plr.ID(), 0)
+}
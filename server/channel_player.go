@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/ban"
 	"github.com/Hucaru/Valhalla/server/field"
+	"github.com/Hucaru/Valhalla/server/field/droppool"
 	"github.com/Hucaru/Valhalla/server/message"
 	"github.com/Hucaru/Valhalla/server/metrics"
 	"github.com/Hucaru/Valhalla/server/movement"
@@ -18,8 +22,11 @@ import (
 )
 
 func (server *ChannelServer) playerConnect(conn mnet.Client, reader mpacket.Reader) {
-	charID := reader.ReadInt32()
+	server.connectCharacter(conn, reader.ReadInt32())
+}
 
+// connectCharacter once its migration ticket is here, a client that arrives before its ticket is held until it does
+func (server *ChannelServer) connectCharacter(conn mnet.Client, charID int32) {
 	var migrationID byte
 	err := server.db.QueryRow("SELECT migrationID FROM characters WHERE id=?", charID).Scan(&migrationID)
 
@@ -32,6 +39,21 @@ func (server *ChannelServer) playerConnect(conn mnet.Client, reader mpacket.Read
 		return
 	}
 
+	if _, ok := server.tickets[charID]; !ok {
+		if !server.waiting.Hold(charID, conn) {
+			log.Println("Refused character", charID, "from", conn, "as another connection is waiting for its ticket")
+		}
+
+		return
+	}
+
+	ticket, ok := server.tickets.Redeem(charID, server.id, ban.IPFromAddr(conn.String()))
+
+	if !ok {
+		log.Println("Refused character", charID, "from", conn, "without a valid migration ticket")
+		return
+	}
+
 	var accountID int32
 	err = server.db.QueryRow("SELECT accountID FROM characters WHERE id=?", charID).Scan(&accountID)
 
@@ -40,6 +62,11 @@ func (server *ChannelServer) playerConnect(conn mnet.Client, reader mpacket.Read
 		return
 	}
 
+	if accountID != ticket.AccountID {
+		log.Println("Refused character", charID, "from", conn, "as the migration ticket is for another account")
+		return
+	}
+
 	conn.SetAccountID(accountID)
 
 	var adminLevel int
@@ -72,6 +99,7 @@ func (server *ChannelServer) playerConnect(conn mnet.Client, reader mpacket.Read
 
 	conn.Send(player.PacketPlayerEnterGame(plr, int32(server.id)))
 	conn.Send(message.PacketMessageScrollingHeader(server.header))
+	plr.RemoveExpiredItems(time.Now(), server.db)
 
 	field, ok := server.fields[plr.MapID()]
 
@@ -101,7 +129,6 @@ func (server *ChannelServer) playerConnect(conn mnet.Client, reader mpacket.Read
 func (server *ChannelServer) playerChangeChannel(conn mnet.Client, reader mpacket.Reader) {
 	id := reader.ReadByte()
 
-	server.migrating = append(server.migrating, conn)
 	player, err := server.players.getFromConn(conn)
 
 	if err != nil {
@@ -109,10 +136,19 @@ func (server *ChannelServer) playerChangeChannel(conn mnet.Client, reader mpacke
 		return
 	}
 
+	if field, ok := server.fields[player.MapID()]; ok && field.Limited(constant.FieldLimitChangeChannel) {
+		conn.Send(message.PacketCannotChangeChannel())
+		return
+	}
+
+	server.migrating = append(server.migrating, conn)
+
 	if int(id) < len(server.channels) {
 		if server.channels[id].port == 0 {
 			conn.Send(message.PacketCannotChangeChannel())
 		} else {
+			server.sendMigrationTicket(conn, player.ID(), id)
+
 			_, err := server.db.Exec("UPDATE characters SET migrationID=? WHERE id=?", id, player.ID())
 
 			if err != nil {
@@ -120,20 +156,37 @@ func (server *ChannelServer) playerChangeChannel(conn mnet.Client, reader mpacke
 				return
 			}
 
-			packetChangeChannel := func(ip []byte, port int16) mpacket.Packet {
-				p := mpacket.CreateWithOpcode(opcode.SendChannelChange)
-				p.WriteBool(true)
-				p.WriteBytes(ip)
-				p.WriteInt16(port)
-
-				return p
-			}
-
 			conn.Send(packetChangeChannel(server.channels[id].ip, server.channels[id].port))
 		}
 	}
 }
 
+func (server *ChannelServer) playerEnterCashShop(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		log.Println("Unable to get player from connection", conn)
+		return
+	}
+
+	if server.cashShop.port == 0 {
+		conn.Send(message.PacketCannotEnterCashShop())
+		return
+	}
+
+	server.sendMigrationTicket(conn, plr.ID(), constant.CashShopID)
+
+	_, err = server.db.Exec("UPDATE characters SET migrationID=?, previousChannelID=? WHERE id=?", constant.CashShopID, server.id, plr.ID())
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server.migrating = append(server.migrating, conn)
+	conn.Send(packetChangeChannel(server.cashShop.ip, server.cashShop.port))
+}
+
 func (server ChannelServer) playerMovement(conn mnet.Client, reader mpacket.Reader) {
 	plr, err := server.players.getFromConn(conn)
 
@@ -148,7 +201,17 @@ func (server ChannelServer) playerMovement(conn mnet.Client, reader mpacket.Read
 
 	moveData, finalData := movement.ParseMovement(reader)
 
-	if !moveData.ValidateChar(plr) {
+	field, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	// Movement that fails validation is not applied and the client is put back near the last good position
+	if violation := moveData.ValidateChar(plr, field.Data.Footholds, field.Data.Swim > 0,
+		!field.Limited(constant.FieldLimitJump), !field.Limited(constant.FieldLimitMovementSkill)); violation != movement.None {
+		server.flagMovement(plr, violation)
+		server.correctPosition(plr, field)
 		return
 	}
 
@@ -156,19 +219,56 @@ func (server ChannelServer) playerMovement(conn mnet.Client, reader mpacket.Read
 
 	plr.UpdateMovement(finalData)
 
-	field, ok := server.fields[plr.MapID()]
+	inst, err := field.GetInstance(plr.InstanceID())
 
-	if !ok {
+	if err != nil {
 		return
 	}
 
-	inst, err := field.GetInstance(plr.InstanceID())
+	inst.MovePlayer(plr.ID(), moveBytes, plr)
+}
+
+// correctPosition moves the client back to the spawn point nearest its last accepted position, the client has no
+// packet to set its position so this is done with a warp within the map
+func (server ChannelServer) correctPosition(plr *player.Data, srcField *field.Field) {
+	inst, err := srcField.GetInstance(plr.InstanceID())
 
 	if err != nil {
 		return
 	}
 
-	inst.MovePlayer(plr.ID(), moveBytes, plr)
+	portalID, err := inst.CalculateNearestSpawnPortalID(plr.Pos())
+
+	if err != nil {
+		return
+	}
+
+	portal, err := inst.GetPortalFromID(portalID)
+
+	if err != nil {
+		return
+	}
+
+	if err := server.warpPlayerToInstance(plr, srcField, inst, portal); err != nil {
+		log.Println(err)
+	}
+}
+
+func (server ChannelServer) flagMovement(plr *player.Data, violation movement.Violation) {
+	plr.AddSuspicion(1)
+
+	if plr.Suspicion()%constant.MovementSuspicionAlert != 0 {
+		return
+	}
+
+	msg := plr.Name() + " suspected of " + violation.String() + " hacking in map " + strconv.Itoa(int(plr.MapID())) +
+		" (suspicion " + strconv.Itoa(plr.Suspicion()) + ")"
+
+	for _, v := range server.players {
+		if v.Admin() {
+			v.Send(message.PacketMessageRedText(msg))
+		}
+	}
 }
 
 func (server ChannelServer) playerEmote(conn mnet.Client, reader mpacket.Reader) {
@@ -203,11 +303,6 @@ func (server ChannelServer) playerEmote(conn mnet.Client, reader mpacket.Reader)
 	inst.SendExcept(packetPlayerEmoticon(plr.ID(), emote), plr.Conn())
 }
 
-func (server ChannelServer) playerUseMysticDoor(conn mnet.Client, reader mpacket.Reader) {
-	// doorID := reader.ReadInt32()
-	// fromTown := reader.ReadBool()
-}
-
 func (server ChannelServer) playerAddStatPoint(conn mnet.Client, reader mpacket.Reader) {
 	player, err := server.players.getFromConn(conn)
 
@@ -276,24 +371,121 @@ func (server ChannelServer) playerPassiveRegen(conn mnet.Client, reader mpacket.
 		return
 	}
 
+	rate := 1.0
+
+	if field, ok := server.fields[player.MapID()]; ok {
+		rate = field.RecoveryRate()
+	}
+
+	chairHP, chairMP := chairRecovery(player.ChairID())
+
 	if hp > 0 {
-		player.GiveHP(int16(hp))
+		player.GiveHP(int16(float64(hp+chairHP) * rate))
 	} else if mp > 0 {
-		player.GiveMP(int16(mp))
+		player.GiveMP(int16(float64(mp+chairMP) * rate))
 	}
 }
 
 func (server ChannelServer) playerUseChair(conn mnet.Client, reader mpacket.Reader) {
-	fmt.Println("use chair:", reader)
-	// chairID := reader.ReadInt32()
+	chairID := reader.ReadInt32()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if chairID/10000 != constant.ChairItemType || plr.ItemCount(chairID) < 1 {
+		conn.Send(packetPlayerSit(-1))
+		return
+	}
+
+	field, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	inst, err := field.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	plr.SetChairID(chairID)
+	inst.SendExcept(packetPlayerShowChair(plr.ID(), chairID), conn)
 }
 
+// playerStand up from a chair or sit on a chair that is part of the map
 func (server ChannelServer) playerStand(conn mnet.Client, reader mpacket.Reader) {
-	fmt.Println(reader)
-	if reader.ReadInt16() == -1 {
+	seat := reader.ReadInt16()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
 
+	field, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	inst, err := field.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	if seat != -1 {
+		conn.Send(packetPlayerSit(seat))
+		return
+	}
+
+	conn.Send(packetPlayerSit(-1))
+
+	if plr.ChairID() != 0 {
+		plr.SetChairID(0)
+		inst.SendExcept(packetPlayerShowChair(plr.ID(), 0), conn)
+	}
+}
+
+// chairRecovery is the hp and mp a portable chair adds to each passive regen
+func chairRecovery(chairID int32) (int16, int16) {
+	if chairID == 0 {
+		return 0, 0
+	}
+
+	info, err := nx.GetItem(chairID)
+
+	if err != nil {
+		return 0, 0
+	}
+
+	return int16(info.RecoveryHP), int16(info.RecoveryMP)
+}
+
+func packetPlayerShowChair(charID, chairID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPlayerShowChair)
+	p.WriteInt32(charID)
+	p.WriteInt32(chairID)
+
+	return p
+}
+
+// packetPlayerSit on a map seat, -1 to stand up
+func packetPlayerSit(seat int16) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPlayerSit)
+
+	if seat == -1 {
+		p.WriteByte(0)
 	} else {
+		p.WriteByte(1)
+		p.WriteInt16(seat)
 	}
+
+	return p
 }
 
 // TODO find better place for this
@@ -513,23 +705,19 @@ func (server ChannelServer) playerUsePortal(conn mnet.Client, reader mpacket.Rea
 		portalName := reader.ReadString(reader.ReadInt16())
 		srcPortal, err := srcInst.GetPortalFromName(portalName)
 
-		if !plr.CheckPos(srcPortal.Pos(), 100, 10) { // trying to account for lag
-			if conn.GetAdminLevel() > 0 {
-				conn.Send(message.PacketMessageRedText("Portal - " + srcPortal.Pos().String() + " Player - " + plr.Pos().String()))
-			}
-
+		if err != nil || !server.canEnterPortal(plr, srcPortal) {
 			conn.Send(packetPlayerNoChange())
 			return
 		}
 
-		if err != nil {
-			conn.Send(packetPlayerNoChange())
+		if srcPortal.Script() != "" {
+			server.runPortalScript(plr, field, srcInst, srcPortal)
 			return
 		}
 
 		dstField, ok := server.fields[srcPortal.DestFieldID()]
 
-		if !ok {
+		if !ok || server.transportBoardingClosed(plr, dstField.ID) {
 			conn.Send(packetPlayerNoChange())
 			return
 		}
@@ -557,6 +745,24 @@ func (server ChannelServer) playerUsePortal(conn mnet.Client, reader mpacket.Rea
 }
 
 func (server ChannelServer) warpPlayer(plr *player.Data, dstField *field.Field, dstPortal field.Portal) error {
+	dstInst, err := dstField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		if dstInst, err = dstField.GetInstance(0); err != nil { // Check player is not in higher level instance than available
+			return err
+		}
+	}
+
+	if !server.instanceAccessible(plr, dstInst) {
+		if dstInst, err = dstField.GetInstance(0); err != nil {
+			return err
+		}
+	}
+
+	return server.warpPlayerToInstance(plr, dstField, dstInst, dstPortal)
+}
+
+func (server ChannelServer) warpPlayerToInstance(plr *player.Data, dstField *field.Field, dstInst *field.Instance, dstPortal field.Portal) error {
 	srcField, ok := server.fields[plr.MapID()]
 
 	if !ok {
@@ -569,18 +775,12 @@ func (server ChannelServer) warpPlayer(plr *player.Data, dstField *field.Field,
 		return err
 	}
 
-	dstInst, err := dstField.GetInstance(plr.InstanceID())
-
-	if err != nil {
-		if dstInst, err = dstField.GetInstance(0); err != nil { // Check player is not in higher level instance than available
-			return err
-		}
-	}
-
 	srcInst.RemovePlayer(plr)
+	server.ownerLeftMysticDoor(plr.ID(), dstField.ID)
 
 	plr.SetMapID(dstField.ID)
 	plr.SetMapPosID(dstPortal.ID())
+	plr.SetChairID(0)
 	plr.SetPos(dstPortal.Pos())
 	// plr.SetFoothold(0)
 
@@ -648,3 +848,57 @@ func (server ChannelServer) playerMoveInventoryItem(conn mnet.Client, reader mpa
 		log.Println(err)
 	}
 }
+
+func (server ChannelServer) playerPickupDrop(conn mnet.Client, reader mpacket.Reader) {
+	reader.Skip(4) // player position
+	dropID := reader.ReadInt32()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	field, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	inst, err := field.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	pool := inst.DropPool()
+	drop, err := pool.GetDrop(dropID)
+
+	if err != nil {
+		plr.Send(message.PacketMessageUnableToPickUp(true))
+		return
+	}
+
+	if !drop.CanLoot(plr.ID(), time.Now()) || !plr.CheckPos(drop.Pos(), constant.DropPlayerPickupRange, constant.DropPlayerPickupRange) {
+		plr.Send(message.PacketMessageUnableToPickUp(false))
+		return
+	}
+
+	if err := server.lootDrop(plr, drop); err != nil {
+		plr.Send(message.PacketMessageUnableToPickUp(false))
+		return
+	}
+
+	pool.PlayerLootDrop(dropID, plr.ID())
+}
+
+// lootDrop gives the contents of the drop to the player, the drop is left on the field if this fails
+func (server ChannelServer) lootDrop(plr *player.Data, drop droppool.Drop) error {
+	if err := plr.GiveItem(drop.Item(), server.db); err != nil {
+		return err
+	}
+
+	plr.Send(message.PacketMessageDropPickUp(false, drop.Item().ID(), int32(drop.Item().Amount())))
+
+	return nil
+}
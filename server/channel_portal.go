@@ -0,0 +1,196 @@
+package server
+
+import (
+	"log"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/field"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/player"
+	"github.com/Hucaru/Valhalla/server/script/portal"
+)
+
+// canEnterPortal checks the portal type can be entered and the player is close enough to it
+func (server ChannelServer) canEnterPortal(plr *player.Data, srcPortal field.Portal) bool {
+	if !srcPortal.Usable() {
+		return false
+	}
+
+	xRange, yRange := int16(constant.PortalUseRangeX), int16(constant.PortalUseRangeY)
+
+	if srcPortal.Collision() {
+		xRange, yRange = constant.PortalCollisionUseRangeX, constant.PortalCollisionUseRangeY
+	}
+
+	if !plr.CheckPos(srcPortal.Pos(), xRange, yRange) { // trying to account for lag
+		if plr.Conn().GetAdminLevel() > 0 {
+			plr.Send(message.PacketMessageRedText("Portal - " + srcPortal.Pos().String() + " Player - " + plr.Pos().String()))
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func (server ChannelServer) playerUseScriptedPortal(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	if plr.PortalCount() != reader.ReadByte() {
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	srcField, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	srcInst, err := srcField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	srcPortal, err := srcInst.GetPortalFromName(reader.ReadString(reader.ReadInt16()))
+
+	if err != nil || srcPortal.Script() == "" || !server.canEnterPortal(plr, srcPortal) {
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	server.runPortalScript(plr, srcField, srcInst, srcPortal)
+}
+
+// runPortalScript runs the portal's script, the client is unlocked if the script did not move the player
+func (server *ChannelServer) runPortalScript(plr *player.Data, srcField *field.Field, srcInst *field.Instance, srcPortal field.Portal) {
+	s, err := portal.Load(srcPortal.Script())
+
+	if err != nil {
+		log.Println("Portal script", srcPortal.Script(), err)
+		plr.Send(message.PacketMessageRedText("This portal is not available"))
+		plr.Send(packetPlayerNoChange())
+		return
+	}
+
+	scriptPlr := &portalScriptPlayer{server: server, plr: plr, portal: srcPortal}
+
+	err = s.Run(portal.Context{
+		Player:   scriptPlr,
+		Field:    portalScriptField{field: srcField},
+		Instance: portalScriptInstance{inst: srcInst},
+		Portal:   portalScriptPortal{portal: srcPortal},
+	})
+
+	if err != nil {
+		log.Println("Portal script", srcPortal.Script(), err)
+	}
+
+	if !scriptPlr.warped {
+		plr.Send(packetPlayerNoChange())
+	}
+}
+
+// portalScriptPlayer is the player object given to portal scripts
+type portalScriptPlayer struct {
+	server *ChannelServer
+	plr    *player.Data
+	portal field.Portal
+	warped bool
+}
+
+func (p portalScriptPlayer) Name() string           { return p.plr.Name() }
+func (p portalScriptPlayer) Level() byte            { return p.plr.Level() }
+func (p portalScriptPlayer) Job() int16             { return p.plr.Job() }
+func (p portalScriptPlayer) Mesos() int32           { return p.plr.Mesos() }
+func (p portalScriptPlayer) ItemCount(id int32) int { return p.plr.ItemCount(id) }
+func (p portalScriptPlayer) MapID() int32           { return p.plr.MapID() }
+func (p portalScriptPlayer) InstanceID() int        { return p.plr.InstanceID() }
+func (p portalScriptPlayer) Admin() bool            { return p.plr.Conn().GetAdminLevel() > 0 }
+func (p portalScriptPlayer) Message(text string)    { p.plr.Send(message.PacketMessageRedText(text)) }
+func (p portalScriptPlayer) Notice(text string)     { p.plr.Send(message.PacketMessageNotice(text)) }
+func (p portalScriptPlayer) InEvent() bool          { return p.server.getPlayerEvent(p.plr.ID()) != nil }
+
+// Enter the portal's own destination
+func (p *portalScriptPlayer) Enter() bool {
+	return p.Warp(p.portal.DestFieldID(), p.portal.DestName())
+}
+
+// Warp to the map keeping the player's instance id where they can enter it
+func (p *portalScriptPlayer) Warp(mapID int32, portalName string) bool {
+	return p.WarpToInstance(mapID, p.plr.InstanceID(), portalName)
+}
+
+// WarpToInstance of the map, falling back to the first instance if the player cannot enter it and to a random spawn
+// point if the portal does not exist
+func (p *portalScriptPlayer) WarpToInstance(mapID int32, instanceID int, portalName string) bool {
+	if p.warped {
+		return false
+	}
+
+	dstField, ok := p.server.fields[mapID]
+
+	if !ok || p.server.transportBoardingClosed(p.plr, mapID) {
+		return false
+	}
+
+	dstInst, err := dstField.GetInstance(instanceID)
+
+	if err != nil || !p.server.instanceAccessible(p.plr, dstInst) {
+		if dstInst, err = dstField.GetInstance(0); err != nil {
+			return false
+		}
+	}
+
+	dstPortal, err := dstInst.GetPortalFromName(portalName)
+
+	if err != nil {
+		if dstPortal, err = dstInst.GetRandomSpawnPortal(); err != nil {
+			return false
+		}
+	}
+
+	if err := p.server.warpPlayerToInstance(p.plr, dstField, dstInst, dstPortal); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	p.warped = true
+
+	return true
+}
+
+// portalScriptField is the field object given to portal scripts
+type portalScriptField struct {
+	field *field.Field
+}
+
+func (f portalScriptField) ID() int32               { return f.field.ID }
+func (f portalScriptField) InstanceCount() int      { return len(f.field.Instances()) }
+func (f portalScriptField) Limited(flag int64) bool { return f.field.Limited(flag) }
+
+// portalScriptInstance is the instance object given to portal scripts
+type portalScriptInstance struct {
+	inst *field.Instance
+}
+
+func (i portalScriptInstance) ID() int            { return i.inst.ID() }
+func (i portalScriptInstance) PlayerCount() int   { return i.inst.PlayerCount() }
+func (i portalScriptInstance) InEvent() bool      { return i.inst.EventListener() != nil }
+func (i portalScriptInstance) Notice(text string) { i.inst.Send(message.PacketMessageNotice(text)) }
+
+// portalScriptPortal is the portal object given to portal scripts
+type portalScriptPortal struct {
+	portal field.Portal
+}
+
+func (p portalScriptPortal) Name() string     { return p.portal.Name() }
+func (p portalScriptPortal) DestMapID() int32 { return p.portal.DestFieldID() }
+func (p portalScriptPortal) DestName() string { return p.portal.DestName() }
@@ -3,9 +3,11 @@ package server
 import (
 	"log"
 
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/server/field/room"
+	"github.com/Hucaru/Valhalla/server/message"
 )
 
 const (
@@ -65,7 +67,14 @@ func (server ChannelServer) roomWindow(conn mnet.Client, reader mpacket.Reader)
 
 	switch operation {
 	case roomCreate:
-		switch roomType := reader.ReadByte(); roomType {
+		roomType := reader.ReadByte()
+
+		if (roomType == roomTypeOmok || roomType == roomTypeMemory) && field.Limited(constant.FieldLimitMiniGame) {
+			conn.Send(message.PacketMessageRedText("Mini games cannot be played here"))
+			return
+		}
+
+		switch roomType {
 		case roomTypeOmok:
 			name := reader.ReadString(reader.ReadInt16())
 
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	skills "github.com/Hucaru/Valhalla/constant/skill"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/field"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/player"
+)
+
+// mysticDoorLocation of one of the doors in a pair
+type mysticDoorLocation struct {
+	fieldID    int32
+	instanceID int
+}
+
+func (server ChannelServer) playerSpecialSkill(conn mnet.Client, reader mpacket.Reader) {
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	skillID := reader.ReadInt32()
+	skillLevel := reader.ReadByte()
+
+	if skill, ok := plr.Skills()[skillID]; !ok || skillLevel == 0 || skill.Level < skillLevel {
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	if field, ok := server.fields[plr.MapID()]; ok && skillID == skills.MysticDoor && field.Limited(constant.FieldLimitMysticDoor) {
+		conn.Send(message.PacketMessageRedText("Mystic door cannot be used here"))
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	switch skillID {
+	case skills.MysticDoor:
+		server.playerCreateMysticDoor(plr, skillID, skillLevel)
+	}
+
+	conn.Send(packetPlayerNoChange())
+}
+
+func (server ChannelServer) playerCreateMysticDoor(plr *player.Data, skillID int32, skillLevel byte) {
+	srcField, ok := server.fields[plr.MapID()]
+
+	if !ok || srcField.Data.Town {
+		return
+	}
+
+	srcInst, err := srcField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	townField, ok := server.fields[srcField.Data.ReturnMap]
+
+	if !ok {
+		return
+	}
+
+	townInst, err := townField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		if townInst, err = townField.GetInstance(0); err != nil {
+			return
+		}
+	}
+
+	townPortal, err := townInst.GetTownDoorPortal()
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	skillData, err := nx.GetPlayerSkill(skillID)
+
+	if err != nil || int(skillLevel) > len(skillData) {
+		return
+	}
+
+	levelData := skillData[skillLevel-1]
+
+	if int64(plr.MP()) < levelData.MpCon {
+		return
+	}
+
+	plr.GiveMP(-int16(levelData.MpCon))
+
+	server.removeMysticDoor(plr.ID())
+
+	expire := time.Now().Add(time.Duration(levelData.Time) * time.Second)
+	field.CreateMysticDoor(plr, srcInst, plr.Pos(), townInst, townPortal, expire)
+
+	server.mysticDoors[plr.ID()] = [2]mysticDoorLocation{
+		{fieldID: srcField.ID, instanceID: srcInst.ID()},
+		{fieldID: townField.ID, instanceID: townInst.ID()},
+	}
+}
+
+// removeMysticDoor pair cast by the owner, used when the owner leaves the channel or the door's maps
+func (server ChannelServer) removeMysticDoor(ownerID int32) {
+	locations, ok := server.mysticDoors[ownerID]
+
+	if !ok {
+		return
+	}
+
+	for _, v := range locations {
+		if field, ok := server.fields[v.fieldID]; ok {
+			if inst, err := field.GetInstance(v.instanceID); err == nil {
+				inst.RemoveMysticDoor(ownerID)
+			}
+		}
+	}
+
+	delete(server.mysticDoors, ownerID)
+}
+
+// ownerLeftMysticDoor removes the owner's doors when they move to a map that is not one end of the pair
+func (server ChannelServer) ownerLeftMysticDoor(ownerID, mapID int32) {
+	locations, ok := server.mysticDoors[ownerID]
+
+	if !ok || locations[0].fieldID == mapID || locations[1].fieldID == mapID {
+		return
+	}
+
+	server.removeMysticDoor(ownerID)
+}
+
+// canUseMysticDoor checks the player is allowed through a door. Party members should be let through as well but
+// there is no party system to check membership against, so only the caster can travel until one exists.
+func canUseMysticDoor(plr *player.Data, door field.MysticDoor) bool {
+	return door.OwnerID() == plr.ID()
+}
+
+func (server ChannelServer) playerUseMysticDoor(conn mnet.Client, reader mpacket.Reader) {
+	doorID := reader.ReadInt32()
+	fromTown := reader.ReadBool()
+
+	plr, err := server.players.getFromConn(conn)
+
+	if err != nil {
+		return
+	}
+
+	srcField, ok := server.fields[plr.MapID()]
+
+	if !ok {
+		return
+	}
+
+	srcInst, err := srcField.GetInstance(plr.InstanceID())
+
+	if err != nil {
+		return
+	}
+
+	door, err := srcInst.GetMysticDoor(doorID)
+
+	if err != nil || door.Town() != fromTown ||
+		!plr.CheckPos(door.Pos(), constant.MysticDoorUseRange, constant.MysticDoorUseRange) {
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	if !canUseMysticDoor(plr, door) {
+		conn.Send(message.PacketMessageRedText("Only the caster can use this mystic door until parties are supported"))
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	dstField, ok := server.fields[door.DestFieldID()]
+
+	if !ok {
+		conn.Send(packetPlayerNoChange())
+		return
+	}
+
+	if err := server.warpPlayer(plr, dstField, door.DestPortal()); err != nil {
+		log.Println(err)
+	}
+}
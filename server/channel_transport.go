@@ -0,0 +1,240 @@
+package server
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/player"
+)
+
+// transportRoute between two towns, departures happen on the wall clock every interval
+type transportRoute struct {
+	name       string
+	stationMap int32 // map the ship docks at, 0 if nothing is shown
+	waitMap    int32 // players in this map are taken on board at departure
+	travelMap  int32
+	destMap    int32
+	interval   time.Duration
+	boarding   time.Duration // boarding opens this long before departure
+	travel     time.Duration
+
+	invasionMob    int32 // mob that can attack mid voyage, 0 for none
+	invasionCount  int
+	invasionChance int // percent chance per voyage
+}
+
+var transportRoutes = []transportRoute{
+	{name: "Ellinia to Orbis", stationMap: 101000300, waitMap: 101000301, travelMap: 200090010, destMap: 200000100,
+		interval: 15 * time.Minute, boarding: 5 * time.Minute, travel: 10 * time.Minute,
+		invasionMob: 8150000, invasionCount: 2, invasionChance: 50},
+	{name: "Orbis to Ellinia", stationMap: 200000111, waitMap: 200000112, travelMap: 200090000, destMap: 101000300,
+		interval: 15 * time.Minute, boarding: 5 * time.Minute, travel: 10 * time.Minute,
+		invasionMob: 8150000, invasionCount: 2, invasionChance: 50},
+	{name: "Orbis to Ludibrium", stationMap: 200000121, waitMap: 200000122, travelMap: 200090100, destMap: 220000110,
+		interval: 10 * time.Minute, boarding: 4 * time.Minute, travel: 5 * time.Minute},
+	{name: "Ludibrium to Orbis", stationMap: 220000110, waitMap: 220000111, travelMap: 200090110, destMap: 200000100,
+		interval: 10 * time.Minute, boarding: 4 * time.Minute, travel: 5 * time.Minute},
+	{name: "Ludibrium elevator up", waitMap: 222020110, travelMap: 222020111, destMap: 222020200,
+		interval: 2 * time.Minute, boarding: time.Minute, travel: time.Minute},
+	{name: "Ludibrium elevator down", waitMap: 222020210, travelMap: 222020211, destMap: 222020100,
+		interval: 2 * time.Minute, boarding: time.Minute, travel: time.Minute},
+}
+
+// departure that is due at or after t
+func (r transportRoute) nextDeparture(t time.Time) time.Time {
+	departure := t.Truncate(r.interval)
+
+	if departure.Before(t) {
+		departure = departure.Add(r.interval)
+	}
+
+	return departure
+}
+
+func (r transportRoute) boardingOpen(t time.Time) bool {
+	return r.nextDeparture(t).Sub(t) <= r.boarding
+}
+
+// crossed returns true if the event at offset from a departure happened in (prev, t]
+func (r transportRoute) crossed(prev, t time.Time, offset time.Duration) bool {
+	at := r.nextDeparture(prev.Add(-offset)).Add(offset)
+
+	if !at.After(prev) {
+		at = at.Add(r.interval)
+	}
+
+	return !at.After(t)
+}
+
+func (server ChannelServer) getTransportRoute(waitMap int32) (transportRoute, bool) {
+	for _, r := range transportRoutes {
+		if r.waitMap == waitMap {
+			return r, true
+		}
+	}
+
+	return transportRoute{}, false
+}
+
+func (server *ChannelServer) transportUpdate(t time.Time) {
+	prev := server.transportTick
+	server.transportTick = t
+
+	if prev.IsZero() {
+		return
+	}
+
+	for _, r := range transportRoutes {
+		if _, ok := server.fields[r.travelMap]; !ok {
+			continue
+		}
+
+		if r.crossed(prev, t, -r.boarding) {
+			server.sendToField(r.stationMap, packetTransportShipState(true))
+		}
+
+		if r.crossed(prev, t, 0) {
+			server.sendToField(r.stationMap, packetTransportShipState(false))
+			server.moveTransportPlayers(r.waitMap, r.travelMap)
+		}
+
+		if r.invasionMob != 0 && r.crossed(prev, t, r.travel/2) && rand.Intn(100) < r.invasionChance {
+			server.startTransportInvasion(r)
+		}
+
+		if r.crossed(prev, t, r.travel) {
+			if r.invasionMob != 0 {
+				server.endTransportInvasion(r)
+			}
+
+			server.moveTransportPlayers(r.travelMap, r.destMap)
+		}
+	}
+}
+
+func (server *ChannelServer) sendToField(fieldID int32, p mpacket.Packet) {
+	if f, ok := server.fields[fieldID]; ok {
+		for _, inst := range f.Instances() {
+			inst.Send(p)
+		}
+	}
+}
+
+func (server *ChannelServer) moveTransportPlayers(srcMap, dstMap int32) {
+	dstField, ok := server.fields[dstMap]
+
+	if !ok {
+		return
+	}
+
+	dstInst, err := dstField.GetInstance(0)
+
+	if err != nil {
+		return
+	}
+
+	for _, plr := range server.players {
+		if plr.MapID() != srcMap {
+			continue
+		}
+
+		portal, err := dstInst.GetRandomSpawnPortal()
+
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		if err := server.warpPlayer(plr, dstField, portal); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// startTransportInvasion spawns the invading mobs on every occupied ship
+func (server *ChannelServer) startTransportInvasion(r transportRoute) {
+	travelField := server.fields[r.travelMap]
+
+	for _, inst := range travelField.Instances() {
+		occupied := false
+
+		for _, plr := range server.players {
+			if plr.MapID() == r.travelMap && plr.InstanceID() == inst.ID() {
+				occupied = true
+				break
+			}
+		}
+
+		if !occupied {
+			continue
+		}
+
+		portal, err := inst.GetRandomSpawnPortal()
+
+		if err != nil {
+			continue
+		}
+
+		for i := 0; i < r.invasionCount; i++ {
+			if err := inst.LifePool().SpawnMobFromID(r.invasionMob, portal.Pos(), true, true, true); err != nil {
+				log.Println(err)
+			}
+		}
+
+		inst.Send(packetTransportInvasion(true))
+	}
+}
+
+func (server *ChannelServer) endTransportInvasion(r transportRoute) {
+	for _, inst := range server.fields[r.travelMap].Instances() {
+		inst.LifePool().RemoveMobsFromID(r.invasionMob)
+		inst.Send(packetTransportInvasion(false))
+	}
+}
+
+// transportBoardingClosed returns true and tells the player if they are trying to board outside of boarding time
+func (server ChannelServer) transportBoardingClosed(plr *player.Data, dstMap int32) bool {
+	r, ok := server.getTransportRoute(dstMap)
+	now := time.Now()
+
+	if !ok || r.boardingOpen(now) {
+		return false
+	}
+
+	opens := r.nextDeparture(now).Add(-r.boarding).Sub(now)
+	plr.Send(message.PacketMessageRedText("Boarding for " + r.name + " opens in " + strconv.Itoa(int(opens.Minutes())+1) + " minute(s)"))
+
+	return true
+}
+
+func packetTransportShipState(docked bool) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelContiState)
+
+	if docked {
+		p.WriteByte(1)
+	} else {
+		p.WriteByte(2)
+	}
+
+	p.WriteByte(0)
+
+	return p
+}
+
+func packetTransportInvasion(start bool) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelContiMove)
+	p.WriteByte(10)
+
+	if start {
+		p.WriteByte(4)
+	} else {
+		p.WriteByte(5)
+	}
+
+	return p
+}
@@ -1,6 +1,9 @@
 package server
 
-import "github.com/Hucaru/Valhalla/mpacket"
+import (
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mpacket"
+)
 
 // PacketClientHandshake sent to client on initial connection
 func PacketClientHandshake(mapleVersion int16, recv, send []byte) mpacket.Packet {
@@ -16,3 +19,12 @@ func PacketClientHandshake(mapleVersion int16, recv, send []byte) mpacket.Packet
 	return p
 
 }
+
+func packetChangeChannel(ip []byte, port int16) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelChange)
+	p.WriteBool(true)
+	p.WriteBytes(ip)
+	p.WriteInt16(port)
+
+	return p
+}
@@ -0,0 +1,143 @@
+package field
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Hucaru/Valhalla/server/pos"
+)
+
+// MysticDoor placed by a player, each door leads to its twin in another field
+type MysticDoor struct {
+	ownerID     int32
+	pos         pos.Data
+	town        bool
+	destFieldID int32
+	destPortal  Portal
+	expireTime  time.Time
+}
+
+// OwnerID of the player who cast the door
+func (d MysticDoor) OwnerID() int32 { return d.ownerID }
+
+// Pos of the door in the field
+func (d MysticDoor) Pos() pos.Data { return d.pos }
+
+// Town door or the door at the cast position
+func (d MysticDoor) Town() bool { return d.town }
+
+// DestFieldID the door takes the player to
+func (d MysticDoor) DestFieldID() int32 { return d.destFieldID }
+
+// DestPortal the player arrives at on the other side
+func (d MysticDoor) DestPortal() Portal { return d.destPortal }
+
+// CreateMysticDoor pair linking the cast position in one instance with the town portal in the town instance
+func CreateMysticDoor(owner player, src *Instance, srcPos pos.Data, town *Instance, townPortal Portal, expire time.Time) {
+	srcPortalID, err := src.CalculateNearestPortalID(srcPos)
+
+	if err != nil {
+		srcPortalID = 0
+	}
+
+	src.addMysticDoor(MysticDoor{
+		ownerID:     owner.ID(),
+		pos:         srcPos,
+		destFieldID: town.fieldID,
+		destPortal:  townPortal,
+		expireTime:  expire,
+	})
+
+	town.addMysticDoor(MysticDoor{
+		ownerID:     owner.ID(),
+		pos:         townPortal.pos,
+		town:        true,
+		destFieldID: src.fieldID,
+		destPortal:  Portal{id: srcPortalID, pos: srcPos, temporary: true},
+		expireTime:  expire,
+	})
+
+	owner.Send(packetMapSpawnTownMysticDoor(town.fieldID, src.fieldID, townPortal.pos))
+}
+
+func (inst *Instance) addMysticDoor(door MysticDoor) {
+	inst.RemoveMysticDoor(door.ownerID)
+	inst.doors = append(inst.doors, door)
+	inst.Send(packetMapSpawnMysticDoor(door.ownerID, door.pos, false))
+
+	// The door needs to expire even if no one has entered this instance
+	if inst.fieldTimer == nil {
+		inst.startFieldTimer()
+	}
+}
+
+// GetMysticDoor in the instance placed by the owner
+func (inst Instance) GetMysticDoor(ownerID int32) (MysticDoor, error) {
+	for _, v := range inst.doors {
+		if v.ownerID == ownerID && time.Now().Before(v.expireTime) {
+			return v, nil
+		}
+	}
+
+	return MysticDoor{}, fmt.Errorf("No mystic door from %d", ownerID)
+}
+
+// RemoveMysticDoor placed by the owner from the instance
+func (inst *Instance) RemoveMysticDoor(ownerID int32) {
+	for i, v := range inst.doors {
+		if v.ownerID == ownerID {
+			inst.doors[i] = inst.doors[len(inst.doors)-1]
+			inst.doors = inst.doors[:len(inst.doors)-1]
+			inst.Send(packetMapRemoveMysticDoor(ownerID, false))
+
+			if plr, err := inst.GetPlayerFromID(ownerID); err == nil {
+				plr.Send(packetMapRemoveTownMysticDoor())
+			}
+
+			return
+		}
+	}
+}
+
+// GetTownDoorPortal returns a town portal not already occupied by a mystic door
+func (inst Instance) GetTownDoorPortal() (Portal, error) {
+	portals := []Portal{}
+
+	for _, p := range inst.portals {
+		if p.name == "tp" {
+			portals = append(portals, p)
+		}
+	}
+
+	if len(portals) == 0 {
+		return Portal{}, fmt.Errorf("No town portals in map")
+	}
+
+	for _, p := range portals {
+		occupied := false
+
+		for _, d := range inst.doors {
+			if d.pos == p.pos {
+				occupied = true
+				break
+			}
+		}
+
+		if !occupied {
+			return p, nil
+		}
+	}
+
+	return portals[0], nil
+}
+
+func (inst *Instance) removeExpiredMysticDoors(t time.Time) {
+	for i := 0; i < len(inst.doors); {
+		if t.After(inst.doors[i].expireTime) {
+			inst.RemoveMysticDoor(inst.doors[i].ownerID)
+			continue
+		}
+
+		i++
+	}
+}
@@ -1,8 +1,42 @@
 package droppool
 
-type drop struct {
-	ownerID int32
-	mesos   bool
-	itemID  int32
-	amount  int16
+import (
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/pos"
+)
+
+// Drop data
+type Drop struct {
+	id         int32
+	ownerID    int32
+	item       item.Data
+	pos        pos.Data
+	dropFrom   pos.Data
+	dropType   byte
+	dropTime   time.Time
+	expireTime time.Time
+}
+
+// ID of the drop within the pool
+func (d Drop) ID() int32 { return d.id }
+
+// OwnerID of the player who has priority in looting the drop
+func (d Drop) OwnerID() int32 { return d.ownerID }
+
+// Item the drop contains
+func (d Drop) Item() item.Data { return d.item }
+
+// Pos of the drop on the field
+func (d Drop) Pos() pos.Data { return d.pos }
+
+// CanLoot checks if the player is allowed to loot the drop at the given time
+func (d Drop) CanLoot(plrID int32, t time.Time) bool {
+	if d.ownerID == plrID || d.dropType == 2 {
+		return true
+	}
+
+	return t.Sub(d.dropTime) >= time.Second*constant.DropFreeForAllTime
 }
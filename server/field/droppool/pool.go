@@ -1,18 +1,30 @@
 package droppool
 
 import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/item"
 	"github.com/Hucaru/Valhalla/server/pos"
 )
 
 type field interface {
 	Send(mpacket.Packet) error
+	ItemDropped(int32, int16)
+}
+
+type sender interface {
+	Send(mpacket.Packet)
 }
 
 // Data structure for the pool
 type Data struct {
 	instance field
-	drops    []drop
+	drops    []Drop
+	poolID   int32
 }
 
 // CreateNewPool for drops
@@ -20,7 +32,103 @@ func CreateNewPool(inst field) Data {
 	return Data{instance: inst}
 }
 
-// CreateMobDrop from a mobID from a player at a given location
-func (data *Data) CreateMobDrop(mesos bool, itemID int32, dropFrom pos.Data, finalPos pos.Data) {
+func (pool *Data) nextID() int32 {
+	pool.poolID++
+
+	if pool.poolID == math.MaxInt32-1 {
+		pool.poolID = 1
+	}
+
+	return pool.poolID
+}
+
+// AddPlayer shows the player all the drops currently on the field
+func (pool Data) AddPlayer(plr sender) {
+	for _, v := range pool.drops {
+		plr.Send(packetShowDrop(2, v))
+	}
+}
+
+// CreatePlayerDrop of an item, dropped by a player at a given location
+func (pool *Data) CreatePlayerDrop(ownerID int32, dropItem item.Data, location pos.Data) {
+	now := time.Now()
+
+	drop := Drop{
+		id:         pool.nextID(),
+		ownerID:    ownerID,
+		item:       dropItem,
+		pos:        location,
+		dropFrom:   location,
+		dropType:   0,
+		dropTime:   now,
+		expireTime: now.Add(time.Second * constant.DropExpireTime),
+	}
+
+	pool.drops = append(pool.drops, drop)
+	pool.instance.Send(packetShowDrop(1, drop))
+
+	pool.instance.ItemDropped(dropItem.ID(), dropItem.Amount())
+}
+
+// GetDrop from the pool via its id
+func (pool Data) GetDrop(id int32) (Drop, error) {
+	for _, v := range pool.drops {
+		if v.id == id {
+			return v, nil
+		}
+	}
+
+	return Drop{}, fmt.Errorf("Could not find drop with id %d", id)
+}
+
+// PlayerLootDrop removes the drop from the field showing it being picked up by the player
+func (pool *Data) PlayerLootDrop(id int32, plrID int32) {
+	if pool.removeDrop(id) {
+		pool.instance.Send(packetRemoveDrop(2, id, plrID, 0))
+	}
+}
+
+// PetLootDrop removes the drop from the field showing it being picked up by the player's pet
+func (pool *Data) PetLootDrop(id int32, plrID int32, petSlot byte) {
+	if pool.removeDrop(id) {
+		pool.instance.Send(packetRemoveDrop(5, id, plrID, petSlot))
+	}
+}
+
+// RemoveDrop from the field without it being picked up
+func (pool *Data) RemoveDrop(id int32) error {
+	if !pool.removeDrop(id) {
+		return fmt.Errorf("Could not find drop with id %d", id)
+	}
+
+	pool.instance.Send(packetRemoveDrop(0, id, 0, 0))
+
+	return nil
+}
+
+func (pool *Data) removeDrop(id int32) bool {
+	for i, v := range pool.drops {
+		if v.id == id {
+			pool.drops[i] = pool.drops[len(pool.drops)-1]
+			pool.drops = pool.drops[:len(pool.drops)-1]
+			return true
+		}
+	}
+
+	return false
+}
+
+// Update the pool, removing any drops that have expired
+func (pool *Data) Update(t time.Time) {
+	for i := 0; i < len(pool.drops); {
+		v := pool.drops[i]
+
+		if t.After(v.expireTime) {
+			pool.removeDrop(v.id)
+			pool.instance.Send(packetRemoveDrop(0, v.id, 0, 0))
+			continue
+		}
 
+		i++
+	}
 }
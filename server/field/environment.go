@@ -0,0 +1,84 @@
+package field
+
+import (
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/server/message"
+)
+
+// environment of a field that acts on the players in it e.g. hp drain and time limits
+type environment struct {
+	decHP        int16
+	protectItem  int32 // item that when equipped stops the hp drain
+	forcedReturn int32
+	lastDecHP    time.Time
+	entered      map[int32]time.Time // player id -> time they entered
+	expel        func(playerID, mapID int32)
+}
+
+func (inst *Instance) timeLimited() bool {
+	// Events run their own clock over the instances they own
+	return inst.timeLimit > 0 && inst.listener == nil
+}
+
+func (inst *Instance) enterEnvironment(plr player) {
+	inst.env.entered[plr.ID()] = time.Now()
+
+	if inst.timeLimited() {
+		plr.Send(message.PacketShowCountdown(int32(inst.timeLimit)))
+	}
+}
+
+func (inst *Instance) leaveEnvironment(plr player) {
+	delete(inst.env.entered, plr.ID())
+
+	if inst.timeLimited() {
+		plr.Send(message.PacketHideCountdown())
+	}
+}
+
+// expelDestination of players whose time in the field has run out
+func (inst Instance) expelDestination() int32 {
+	if inst.env.forcedReturn != 0 && inst.env.forcedReturn != constant.FieldNoReturnMap {
+		return inst.env.forcedReturn
+	}
+
+	return inst.returnMapID
+}
+
+func (inst *Instance) updateEnvironment(t time.Time) {
+	if inst.env.decHP > 0 && t.Sub(inst.env.lastDecHP) >= constant.FieldDecHPInterval*time.Second {
+		inst.env.lastDecHP = t
+
+		for _, plr := range inst.players {
+			if plr.HP() == 0 || (inst.env.protectItem != 0 && plr.Equipped(inst.env.protectItem)) {
+				continue
+			}
+
+			plr.GiveHP(-inst.env.decHP)
+		}
+	}
+
+	if !inst.timeLimited() || inst.env.expel == nil {
+		return
+	}
+
+	dst := inst.expelDestination()
+
+	if dst == constant.FieldNoReturnMap {
+		return
+	}
+
+	expired := []int32{}
+
+	for id, entered := range inst.env.entered {
+		if t.Sub(entered) >= time.Duration(inst.timeLimit)*time.Second {
+			expired = append(expired, id)
+		}
+	}
+
+	for _, id := range expired {
+		inst.env.expel(id, dst)
+	}
+}
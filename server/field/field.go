@@ -3,8 +3,10 @@ package field
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/field/droppool"
 	"github.com/Hucaru/Valhalla/server/field/lifepool"
 	"github.com/Hucaru/Valhalla/server/field/rectangle"
 )
@@ -18,6 +20,8 @@ type Field struct {
 	deltaX, deltaY float64
 
 	Dispatch chan func()
+	Expel    func(playerID, mapID int32) // sends a player out of the field e.g. when its time limit runs out
+	ExpRate  func() float32              // world exp rate applied to mob kills
 
 	vrLimit                        rectangle.Data
 	mobCapacityMin, mobCapacityMax int
@@ -38,14 +42,23 @@ func (f *Field) CreateInstance() int {
 		fieldID:     f.ID,
 		portals:     portals,
 		dispatch:    f.Dispatch,
+		expRate:     f.ExpRate,
 		town:        f.Data.Town,
 		returnMapID: f.Data.ReturnMap,
 		timeLimit:   f.Data.TimeLimit,
+		env: environment{
+			decHP:        int16(f.Data.DecHP),
+			protectItem:  f.Data.ProtectItem,
+			forcedReturn: int32(f.Data.ForcedReturn),
+			entered:      make(map[int32]time.Time),
+			expel:        f.Expel,
+		},
 	}
 
 	lifePool := lifepool.CreatNewPool(inst, f.Data.NPCs, f.Data.Mobs, f.mobCapacityMin, f.mobCapacityMax)
 
 	inst.lifePool = lifePool
+	inst.dropPool = droppool.CreateNewPool(inst)
 
 	f.instances = append(f.instances, inst)
 
@@ -178,6 +191,10 @@ func (f *Field) DeleteInstance(id int) error {
 
 		f.instances = append(f.instances[:id], f.instances[id+1:]...)
 
+		for i := id; i < len(f.instances); i++ {
+			f.instances[i].id = i
+		}
+
 		return nil
 	}
 	return fmt.Errorf("Invalid instance")
@@ -192,6 +209,20 @@ func (f *Field) GetInstance(id int) (*Instance, error) {
 	return nil, fmt.Errorf("Invalid instance id")
 }
 
+// Limited returns true if the field has the given field limit flag set e.g. constant.FieldLimitChangeChannel
+func (f Field) Limited(flag int64) bool {
+	return f.Data.FieldLimit&flag == flag
+}
+
+// RecoveryRate that passive regen is scaled by
+func (f Field) RecoveryRate() float64 {
+	if f.Data.Recovery > 0 {
+		return f.Data.Recovery
+	}
+
+	return 1
+}
+
 // Instances in field
 func (f *Field) Instances() []*Instance {
 	return f.instances
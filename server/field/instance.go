@@ -8,6 +8,7 @@ import (
 
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/field/droppool"
 	"github.com/Hucaru/Valhalla/server/field/lifepool"
 	"github.com/Hucaru/Valhalla/server/field/room"
 	"github.com/Hucaru/Valhalla/server/pos"
@@ -24,6 +25,10 @@ type player interface {
 	ChairID() int32
 	Stance() byte
 	Send(mpacket.Packet)
+	PetSpawnPacket() (mpacket.Packet, bool)
+	HP() int16
+	GiveHP(int16)
+	Equipped(int32) bool
 	MiniGameWins() int32
 	MiniGameDraw() int32
 	MiniGameLoss() int32
@@ -42,6 +47,12 @@ type players interface {
 	GetFromConn(mnet.Client) (player, error)
 }
 
+// EventListener is told about what happens inside the instances of an event
+type EventListener interface {
+	MobKilled(mobID int32)
+	ItemDropped(itemID int32, amount int16)
+}
+
 // Instance data for a field
 type Instance struct {
 	id          int
@@ -50,17 +61,37 @@ type Instance struct {
 	timeLimit   int64
 
 	lifePool lifepool.Data
+	dropPool droppool.Data
 
 	portals []Portal
 	players []player
 
 	rooms []room.Room
+	doors []MysticDoor
 
 	fieldTimer *time.Ticker
 	idCounter  int32
 	town       bool
 
+	listener EventListener
+	env      environment
+
 	dispatch chan func()
+	expRate  func() float32
+}
+
+// ExpRate mob kills in the instance give
+func (inst Instance) ExpRate() float32 {
+	if inst.expRate == nil {
+		return 1
+	}
+
+	return inst.expRate()
+}
+
+// PlayerCount in the instance
+func (inst Instance) PlayerCount() int {
+	return len(inst.players)
 }
 
 // ID of the instance within the field
@@ -68,7 +99,35 @@ func (inst Instance) ID() int {
 	return inst.id
 }
 
+// SetEventListener for the instance, nil once the event no longer owns it
+func (inst *Instance) SetEventListener(listener EventListener) {
+	inst.listener = listener
+}
+
+// EventListener that owns the instance, nil if it is not part of an event
+func (inst Instance) EventListener() EventListener {
+	return inst.listener
+}
+
+// MobKilled in the instance
+func (inst *Instance) MobKilled(mobID int32) {
+	if inst.listener != nil {
+		inst.listener.MobKilled(mobID)
+	}
+}
+
+// ItemDropped onto the instance by a player
+func (inst *Instance) ItemDropped(itemID int32, amount int16) {
+	if inst.listener != nil {
+		inst.listener.ItemDropped(itemID, amount)
+	}
+}
+
 func (inst *Instance) delete() error {
+	if inst.fieldTimer != nil {
+		inst.stopFieldTimer()
+	}
+
 	return nil
 }
 
@@ -89,6 +148,11 @@ func (inst *Instance) LifePool() *lifepool.Data {
 	return &inst.lifePool
 }
 
+// DropPool pointer for instance
+func (inst *Instance) DropPool() *droppool.Data {
+	return &inst.dropPool
+}
+
 // FindController in instance, need to return interface for casting
 func (inst Instance) FindController() interface{} {
 	for _, v := range inst.players {
@@ -105,9 +169,18 @@ func (inst *Instance) AddPlayer(plr player) error {
 	for _, other := range inst.players {
 		other.Send(packetMapPlayerEnter(plr))
 		plr.Send(packetMapPlayerEnter(other))
+
+		if p, ok := other.PetSpawnPacket(); ok {
+			plr.Send(p)
+		}
 	}
 
 	inst.lifePool.AddPlayer(plr)
+	inst.dropPool.AddPlayer(plr)
+
+	for _, v := range inst.doors {
+		plr.Send(packetMapSpawnMysticDoor(v.ownerID, v.pos, true))
+	}
 
 	// show all the rooms
 	for _, v := range inst.rooms {
@@ -119,8 +192,14 @@ func (inst *Instance) AddPlayer(plr player) error {
 	// Play map animations e.g. ship arriving to dock
 
 	inst.players = append(inst.players, plr)
+	inst.enterEnvironment(plr)
+
+	// Pets follow their owner between fields
+	if p, ok := plr.PetSpawnPacket(); ok {
+		inst.Send(p)
+	}
 
-	if len(inst.players) == 1 {
+	if inst.fieldTimer == nil {
 		inst.startFieldTimer()
 	}
 
@@ -143,6 +222,7 @@ func (inst *Instance) RemovePlayer(plr player) error {
 	}
 
 	inst.players = append(inst.players[:index], inst.players[index+1:]...)
+	inst.leaveEnvironment(plr)
 
 	for _, v := range inst.players {
 		v.Send(packetMapPlayerLeft(plr.ID()))
@@ -303,6 +383,23 @@ func (inst Instance) CalculateNearestSpawnPortalID(pos pos.Data) (byte, error) {
 	return portal.id, err
 }
 
+// CalculateNearestPortalID of any type from a given position
+func (inst Instance) CalculateNearestPortalID(pos pos.Data) (byte, error) {
+	if len(inst.portals) == 0 {
+		return 0, fmt.Errorf("Portal not found")
+	}
+
+	portal := inst.portals[0]
+
+	for _, p := range inst.portals[1:] {
+		if p.pos.CalcDistanceSquare(pos) < portal.pos.CalcDistanceSquare(pos) {
+			portal = p
+		}
+	}
+
+	return portal.id, nil
+}
+
 // GetPortalFromName in the current instance
 func (inst Instance) GetPortalFromName(name string) (Portal, error) {
 	for _, p := range inst.portals {
@@ -339,7 +436,10 @@ func (inst *Instance) stopFieldTimer() {
 	inst.fieldTimer.Stop()
 }
 
-// Responsible for hadnling the removing of mystic doors, disappearence of loot, ships coming and going
+// Responsible for hadnling the removing of mystic doors, disappearence of loot, mob spawns and the field environment, ships are scheduled by the channel
 func (inst *Instance) fieldUpdate(t time.Time) {
 	inst.lifePool.Update(t)
+	inst.dropPool.Update(t)
+	inst.removeExpiredMysticDoors(t)
+	inst.updateEnvironment(t)
 }
@@ -19,6 +19,8 @@ type field interface {
 	Send(mpacket.Packet) error
 	SendExcept(mpacket.Packet, mnet.Client) error
 	FindController() interface{}
+	MobKilled(int32)
+	ExpRate() float32
 }
 
 type controller interface {
@@ -137,6 +139,17 @@ func (pool Data) GetNPCFromSpawnID(id int32) (npc.Data, error) {
 	return npc.Data{}, fmt.Errorf("Could not find npc with id %d", id)
 }
 
+// GetMobFromSpawnID - get mob data from spawn id
+func (pool Data) GetMobFromSpawnID(id int32) (mob.Data, error) {
+	for _, v := range pool.mobs {
+		if v.SpawnID() == id {
+			return v, nil
+		}
+	}
+
+	return mob.Data{}, fmt.Errorf("Could not find mob with spawn id %d", id)
+}
+
 // AddPlayer to be added to the pool
 func (pool *Data) AddPlayer(plr controller) {
 	for i, npc := range pool.npcs {
@@ -247,6 +260,8 @@ func (pool *Data) MobDamaged(poolID int32, damager player, prty party, dmg ...in
 			pool.showMobBossHPBar(v)
 
 			if pool.mobs[i].HP() < 1 {
+				exp := int32(float64(v.Exp()) * float64(pool.instance.ExpRate()))
+
 				for cont, dmg := range pool.mobs[i].GetDamage() {
 					plr, ok := cont.(player)
 
@@ -259,11 +274,11 @@ func (pool *Data) MobDamaged(poolID int32, damager player, prty party, dmg ...in
 					}
 
 					if dmg == v.MaxHP() {
-						plr.GiveEXP(v.Exp(), true, false)
+						plr.GiveEXP(exp, true, false)
 					} else if float64(dmg)/float64(v.MaxHP()) > 0.60 {
-						plr.GiveEXP(v.Exp(), true, false)
+						plr.GiveEXP(exp, true, false)
 					} else {
-						newExp := int32(float64(v.Exp()) * 0.25)
+						newExp := int32(float64(exp) * 0.25)
 
 						if newExp == 0 {
 							newExp = 1
@@ -278,6 +293,7 @@ func (pool *Data) MobDamaged(poolID int32, damager player, prty party, dmg ...in
 				}
 
 				// quest mob logic
+				pool.instance.MobKilled(v.ID())
 
 				// on die logic
 				for _, id := range v.Revives() {
@@ -310,6 +326,21 @@ func (pool *Data) MobDamaged(poolID int32, damager player, prty party, dmg ...in
 	}
 }
 
+// RemoveMobsFromID without killing them e.g. invaders leaving once a ship docks
+func (pool *Data) RemoveMobsFromID(mobID int32) {
+	ids := []int32{}
+
+	for _, v := range pool.mobs {
+		if v.ID() == mobID {
+			ids = append(ids, v.SpawnID())
+		}
+	}
+
+	for _, id := range ids {
+		pool.removeMob(id, 0x0)
+	}
+}
+
 // KillMobs in the pool
 func (pool *Data) KillMobs(deathType byte) {
 	for _, v := range pool.mobs {
@@ -82,16 +82,20 @@ func packetMapSpawnMysticDoor(spawnID int32, pos pos.Data, instant bool) mpacket
 	return p
 }
 
-func packetMapSpawnTownMysticDoor(dstMap int32, destPos pos.Data) mpacket.Packet {
+func packetMapSpawnTownMysticDoor(townID, fieldID int32, destPos pos.Data) mpacket.Packet {
 	p := mpacket.CreateWithOpcode(opcode.SendChannelTownPortal)
-	p.WriteInt32(dstMap)
-	p.WriteInt32(dstMap)
+	p.WriteInt32(townID)
+	p.WriteInt32(fieldID)
 	p.WriteInt16(destPos.X())
 	p.WriteInt16(destPos.Y())
 
 	return p
 }
 
+func packetMapRemoveTownMysticDoor() mpacket.Packet {
+	return packetMapSpawnTownMysticDoor(999999999, 999999999, pos.Data{})
+}
+
 func packetMapRemoveMysticDoor(spawnID int32, instant bool) mpacket.Packet {
 	p := mpacket.CreateWithOpcode(opcode.SendChannelRemoveDoor)
 	p.WriteBool(instant)
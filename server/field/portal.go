@@ -1,6 +1,7 @@
 package field
 
 import (
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/nx"
 	"github.com/Hucaru/Valhalla/server/pos"
 )
@@ -12,6 +13,8 @@ type Portal struct {
 	name        string
 	destFieldID int32
 	destName    string
+	portalType  byte
+	script      string
 	temporary   bool
 }
 
@@ -21,6 +24,8 @@ func createPortalFromData(p nx.Portal) Portal {
 		name:        p.Pn,
 		destFieldID: p.Tm,
 		destName:    p.Tn,
+		portalType:  byte(p.Pt),
+		script:      p.Script,
 		temporary:   false}
 }
 
@@ -35,3 +40,27 @@ func (p Portal) DestFieldID() int32 { return p.destFieldID }
 
 // DestName of the portal on the other side
 func (p Portal) DestName() string { return p.destName }
+
+// Name of the portal
+func (p Portal) Name() string { return p.name }
+
+// Type of the portal e.g. constant.PortalCollision
+func (p Portal) Type() byte { return p.portalType }
+
+// Script the portal runs when entered, empty if it is not scripted
+func (p Portal) Script() string { return p.script }
+
+// Usable by walking into it, spawn points and town portal points cannot be entered
+func (p Portal) Usable() bool {
+	return p.portalType != constant.PortalStart && p.portalType != constant.PortalTownPortal
+}
+
+// Collision portals are entered by touching them rather than pressing up
+func (p Portal) Collision() bool {
+	switch p.portalType {
+	case constant.PortalCollision, constant.PortalCollisionScript, constant.PortalCollisionVerticalJump, constant.PortalCollisionCustomImpact:
+		return true
+	}
+
+	return false
+}
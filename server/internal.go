@@ -1,6 +1,9 @@
 package server
 
 import (
+	"math"
+	"math/rand"
+
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
@@ -43,6 +46,48 @@ func (w *world) serialisePacket(reader mpacket.Reader) {
 	}
 }
 
+// rates a world's channels multiply exp, drops and mesos by
+type rates struct {
+	exp, drop, mesos float32
+}
+
+func (r *rates) sanitise() {
+	for _, v := range []*float32{&r.exp, &r.drop, &r.mesos} {
+		if *v <= 0 {
+			*v = 1
+		}
+	}
+}
+
+// dropCount is how many times an item drop is given at the drop rate, the fraction of the rate is the chance of one more
+func (r rates) dropCount() int {
+	count := int(r.drop)
+
+	if rand.Float32() < r.drop-float32(count) {
+		count++
+	}
+
+	return count
+}
+
+func (r rates) generatePacket() mpacket.Packet {
+	p := mpacket.CreateInternal(opcode.WorldRates)
+	r.write(&p)
+	return p
+}
+
+func (r rates) write(p *mpacket.Packet) {
+	p.WriteUint32(math.Float32bits(r.exp))
+	p.WriteUint32(math.Float32bits(r.drop))
+	p.WriteUint32(math.Float32bits(r.mesos))
+}
+
+func (r *rates) serialisePacket(reader *mpacket.Reader) {
+	r.exp = math.Float32frombits(reader.ReadUint32())
+	r.drop = math.Float32frombits(reader.ReadUint32())
+	r.mesos = math.Float32frombits(reader.ReadUint32())
+}
+
 type channel struct {
 	conn        mnet.Server
 	ip          []byte
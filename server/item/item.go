@@ -20,7 +20,7 @@ type Data struct {
 	invID        byte
 	slotID       int16
 	id           int32
-	expireTime   int64
+	expireTime   int64 // unix seconds, zero if permanent, only packets use file time
 	amount       int16
 	creatorName  string
 	flag         int16
@@ -49,9 +49,15 @@ type Data struct {
 	attackSpeed  int16
 	stand        byte
 
-	weaponType byte
-	twoHanded  bool
-	pet        bool
+	weaponType     byte
+	twoHanded      bool
+	pet            bool
+	expireOnLogout bool
+
+	petName      string
+	petLevel     byte
+	petCloseness int16
+	petFullness  byte
 }
 
 func (v Data) DbID() int64         { return v.dbID }
@@ -81,6 +87,10 @@ func (v Data) CreatorName() string { return v.creatorName }
 func (v Data) Flag() int16         { return v.flag }
 func (v Data) ExpireTime() int64   { return v.expireTime }
 func (v Data) Amount() int16       { return v.amount }
+func (v Data) PetName() string     { return v.petName }
+func (v Data) PetLevel() byte      { return v.petLevel }
+func (v Data) PetCloseness() int16 { return v.petCloseness }
+func (v Data) PetFullness() byte   { return v.petFullness }
 
 // LoadInventoryFromDb gets the inventory for a given database connection and character id, returning equip, use, set-up, etc and cash slices
 func LoadInventoryFromDb(db *sql.DB, charID int32) ([]Data, []Data, []Data, []Data, []Data) {
@@ -129,6 +139,12 @@ func LoadInventoryFromDb(db *sql.DB, charID int32) ([]Data, []Data, []Data, []Da
 			&item.expireTime,
 			&item.creatorName)
 
+		if nxInfo, err := nx.GetItem(item.id); err == nil {
+			item.cash = nxInfo.Cash
+			item.pet = nxInfo.Pet
+			item.expireOnLogout = nxInfo.ExpireOnLogout != 0
+		}
+
 		item.calculateWeaponType()
 
 		switch item.invID {
@@ -147,6 +163,20 @@ func LoadInventoryFromDb(db *sql.DB, charID int32) ([]Data, []Data, []Data, []Da
 
 	}
 
+	for i, v := range cash {
+		if !v.pet {
+			continue
+		}
+
+		err := db.QueryRow("SELECT name,level,closeness,fullness FROM pets WHERE id=?", v.dbID).Scan(&cash[i].petName,
+			&cash[i].petLevel, &cash[i].petCloseness, &cash[i].petFullness)
+
+		if err != nil {
+			cash[i].petLevel = 1
+			cash[i].petFullness = constant.PetMaxFullness
+		}
+	}
+
 	return equip, use, setUp, etc, cash
 }
 
@@ -205,6 +235,12 @@ func createItemFromID(id int32, amount int16, bias int8) (Data, error) {
 	newItem.reqLevel = nxInfo.ReqLevel
 	newItem.upgradeSlots = nxInfo.Tuc
 	newItem.pet = nxInfo.Pet
+	newItem.expireOnLogout = nxInfo.ExpireOnLogout != 0
+
+	if newItem.pet {
+		newItem.petLevel = 1
+		newItem.petFullness = constant.PetMaxFullness
+	}
 
 	if amount < 1 {
 		amount = 1
@@ -278,6 +314,27 @@ func (v *Data) SetAmount(value int16) {
 	v.amount = value
 }
 
+// SetExpireTime in unix seconds, zero for permanent
+func (v *Data) SetExpireTime(t int64) {
+	v.expireTime = t
+}
+
+func (v *Data) SetPetName(name string) {
+	v.petName = name
+}
+
+func (v *Data) SetPetLevel(level byte) {
+	v.petLevel = level
+}
+
+func (v *Data) SetPetCloseness(closeness int16) {
+	v.petCloseness = closeness
+}
+
+func (v *Data) SetPetFullness(fullness byte) {
+	v.petFullness = fullness
+}
+
 func (v Data) IsStackable() bool {
 	bullet := v.id / 1e4
 
@@ -304,8 +361,65 @@ func (v Data) Shield() bool {
 	return v.weaponType == 17
 }
 
+// EquipSlotValid checks the item can be worn in the equip slot, cash items are worn in the slot 100 below
+func (v Data) EquipSlotValid(slot int16) bool {
+	if slot <= -100 {
+		slot += 100
+	}
+
+	switch v.id / 10000 {
+	case 100:
+		return slot == -1 // cap
+	case 101:
+		return slot == -2 // face accessory
+	case 102:
+		return slot == -3 // eye accessory
+	case 103:
+		return slot == -4 // earrings
+	case 104, 105:
+		return slot == -5 // top, overall
+	case 106:
+		return slot == -6 // bottom
+	case 107:
+		return slot == -7 // shoes
+	case 108:
+		return slot == -8 // gloves
+	case 109:
+		return slot == -10 // shield
+	case 110:
+		return slot == -9 // cape
+	case 111:
+		return slot == -12 || slot == -13 || slot == -15 || slot == -16 // rings
+	case 170:
+		return slot == -11 // cash weapon cover
+	case constant.PetEquipItemType:
+		return slot == constant.PetEquipSlot
+	}
+
+	if v.weaponType != 0 && !v.Shield() {
+		return slot == -11
+	}
+
+	return v.invID == 1 && v.id/10000 > constant.PetEquipItemType // mounts and other slots the client manages
+}
+
+// Expired returns true if the item has an expiry time and it has passed, both are compared as unix seconds
+func (v Data) Expired(t time.Time) bool {
+	return v.expireTime > 0 && v.expireTime <= t.Unix()
+}
+
+// ExpireOnLogout returns true if the item is removed when the player leaves the channel
+func (v Data) ExpireOnLogout() bool {
+	return v.expireOnLogout
+}
+
+// WeaponType of the item, 0 if it is not a weapon
+func (v Data) WeaponType() byte {
+	return v.weaponType
+}
+
 // Save item to database
-func (v Data) Save(db *sql.DB, charID int32) (bool, error) {
+func (v *Data) Save(db *sql.DB, charID int32) (bool, error) {
 	if v.dbID == 0 {
 		props := `characterID,inventoryID,itemID,slotNumber,amount,flag,upgradeSlots,level,
 				str,dex,intt,luk,hp,mp,watk,matk,wdef,mdef,accuracy,avoid,hands,speed,jump,
@@ -343,6 +457,17 @@ func (v Data) Save(db *sql.DB, charID int32) (bool, error) {
 			return false, err
 		}
 	}
+
+	if v.pet {
+		query := "INSERT INTO pets(id,name,level,closeness,fullness) VALUES(?,?,?,?,?) ON DUPLICATE KEY UPDATE name=?,level=?,closeness=?,fullness=?"
+		_, err := db.Exec(query, v.dbID, v.petName, v.petLevel, v.petCloseness, v.petFullness,
+			v.petName, v.petLevel, v.petCloseness, v.petFullness)
+
+		if err != nil {
+			return false, err
+		}
+	}
+
 	return true, nil
 }
 
@@ -358,6 +483,15 @@ func (v Data) Delete(db *sql.DB) error {
 	return nil
 }
 
+// Filetime converts an expire time in unix seconds to the windows file time the client expects, permanent stays zero
+func Filetime(expireTime int64) int64 {
+	if expireTime == 0 {
+		return 0
+	}
+
+	return (expireTime + 11644473600) * 10000000
+}
+
 // InventoryBytes to display in character inventory window
 func (v Data) InventoryBytes() []byte {
 	return v.bytes(false)
@@ -396,7 +530,7 @@ func (v Data) bytes(shortSlot bool) []byte {
 		p.WriteUint64(uint64(v.id)) // I think this is somekind of cashshop transaction ID for the item
 	}
 
-	p.WriteInt64(v.expireTime)
+	p.WriteInt64(Filetime(v.expireTime))
 
 	if v.invID == 1 {
 		p.WriteByte(v.upgradeSlots)
@@ -419,11 +553,11 @@ func (v Data) bytes(shortSlot bool) []byte {
 		p.WriteString(v.creatorName)
 		p.WriteInt16(v.flag) // lock/seal, show, spikes, cape, cold protection etc ?
 	} else if v.pet {
-		p.WritePaddedString(v.creatorName, 13)
-		p.WriteByte(0)
-		p.WriteInt16(0)
-		p.WriteByte(0)
-		p.WriteInt64(v.expireTime)
+		p.WritePaddedString(v.petName, 13)
+		p.WriteByte(v.petLevel)
+		p.WriteInt16(v.petCloseness)
+		p.WriteByte(v.petFullness)
+		p.WriteInt64(Filetime(v.expireTime))
 		p.WriteInt32(0) // ?
 	} else {
 		p.WriteInt16(v.amount)
@@ -3,11 +3,14 @@ package server
 import (
 	"database/sql"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/player"
 )
 
 // LoginServer state
@@ -15,11 +18,25 @@ type LoginServer struct {
 	migrating map[mnet.Client]bool
 	db        *sql.DB
 	worlds    []world
+
+	pinEnabled   bool
+	autoRegister bool
+	pinVerified  map[mnet.Client]bool
+	pinChanging  map[mnet.Client]bool
+
+	attempts map[string]*loginAttempts // failed logins by account and ip, failed pins by account id
+	pending  map[mnet.Client]time.Time // connections that have not logged in yet
+
+	lastPurge time.Time // last time deleted characters past their grace period were purged
 }
 
 // Initialise the server
 func (server *LoginServer) Initialise(dbuser, dbpassword, dbaddress, dbport, dbdatabase string) {
 	server.migrating = make(map[mnet.Client]bool)
+	server.pinVerified = make(map[mnet.Client]bool)
+	server.pinChanging = make(map[mnet.Client]bool)
+	server.attempts = make(map[string]*loginAttempts)
+	server.pending = make(map[mnet.Client]time.Time)
 
 	var err error
 	server.db, err = sql.Open("mysql", dbuser+":"+dbpassword+"@tcp("+dbaddress+":"+dbport+")/"+dbdatabase)
@@ -51,6 +68,29 @@ func (server *LoginServer) CleanupDB() {
 	log.Printf("Set %d isLogedin rows to 0.", amount)
 }
 
+// Update the login server every tick
+func (server *LoginServer) Update(t time.Time) {
+	server.updateThrottle(t)
+
+	if t.Sub(server.lastPurge) > time.Second*constant.CharacterPurgeInterval {
+		server.lastPurge = t
+		server.purgeDeletedCharacters(t)
+	}
+}
+
+// purgeDeletedCharacters that are past their restore window
+func (server *LoginServer) purgeDeletedCharacters(t time.Time) {
+	n, err := player.PurgeDeleted(server.db, t)
+
+	if err != nil {
+		log.Println("Unable to purge deleted characters:", err)
+	}
+
+	if n > 0 {
+		log.Println("Purged", n, "deleted characters")
+	}
+}
+
 // HandleServerPacket from world
 func (server *LoginServer) HandleServerPacket(conn mnet.Server, reader mpacket.Reader) {
 	switch reader.ReadByte() {
@@ -69,72 +109,68 @@ func (server *LoginServer) ServerDisconnected(conn mnet.Server) {
 		if v.conn == conn {
 			log.Println(v.name, "disconnected")
 			server.worlds[i].conn = nil
-			server.worlds[i].channels = []channel{}
+
+			for j := range server.worlds[i].channels {
+				server.worlds[i].channels[j].pop = 0
+				server.worlds[i].channels[j].maxPop = 0
+			}
+
 			break
 		}
 	}
 }
 
-// The following logic could do with being cleaned up
+// handleNewWorld registers a world server under the id and name from its config
 func (server *LoginServer) handleNewWorld(conn mnet.Server, reader mpacket.Reader) {
-	log.Println("Server register request from", conn)
-	if len(server.worlds) > 14 {
-		log.Println("Rejected")
-		conn.Send(mpacket.CreateInternal(opcode.WorldRequestBad))
-	} else {
-		name := reader.ReadString(reader.ReadInt16())
+	id := reader.ReadByte()
+	name := reader.ReadString(reader.ReadInt16())
 
-		if name == "" {
-			name = constant.WORLD_NAMES[len(server.worlds)]
+	log.Println("Server register request from", conn, "for world", id, name)
 
-			registered := false
-			for i, v := range server.worlds {
-				if v.conn == nil {
-					server.worlds[i].conn = conn
-					name = server.worlds[i].name
+	reject := func(reason string) {
+		log.Println("Rejected world", id, name+":", reason)
 
-					registered = true
-					break
-				}
-			}
-
-			if !registered {
-				server.worlds = append(server.worlds, world{conn: conn, name: name})
-			}
+		p := mpacket.CreateInternal(opcode.WorldRequestBad)
+		p.WriteString(reason)
+		conn.Send(p)
+	}
 
-			p := mpacket.CreateInternal(opcode.WorldRequestOk)
-			p.WriteString(name)
-			conn.Send(p)
+	if int(id) >= constant.MaxWorlds {
+		reject("world id must be less than " + strconv.Itoa(constant.MaxWorlds))
+		return
+	}
 
-			log.Println("Registered", name)
-		} else {
-			registered := false
-			for i, w := range server.worlds {
-				if w.name == name {
-					server.worlds[i].conn = conn
-					server.worlds[i].name = name
+	if name == "" {
+		reject("world has no name")
+		return
+	}
 
-					p := mpacket.CreateInternal(opcode.WorldRequestOk)
-					p.WriteString(name)
-					conn.Send(p)
+	for i, v := range server.worlds {
+		if i == int(id) && v.conn != nil {
+			reject("world id is already registered by " + v.name)
+			return
+		} else if i != int(id) && v.name == name {
+			reject("world name is already used by world " + strconv.Itoa(i))
+			return
+		}
+	}
 
-					registered = true
+	for len(server.worlds) <= int(id) {
+		server.worlds = append(server.worlds, world{})
+	}
 
-					break
-				}
-			}
+	if old := server.worlds[id].name; old != "" && old != name {
+		log.Println("World", id, "renamed from", old, "to", name)
+	}
 
-			if !registered {
-				server.worlds = append(server.worlds, world{conn: conn, name: name})
+	server.worlds[id].conn = conn
+	server.worlds[id].name = name
 
-				p := mpacket.CreateInternal(opcode.WorldRequestOk)
-				p.WriteString(server.worlds[len(server.worlds)-1].name)
-				conn.Send(p)
-			}
+	p := mpacket.CreateInternal(opcode.WorldRequestOk)
+	p.WriteString(name)
+	conn.Send(p)
 
-			log.Println("Re-registered", name)
-		}
-	}
+	log.Println("Registered world", id, name)
 }
 
 func (server *LoginServer) handleWorldInfo(conn mnet.Server, reader mpacket.Reader) {
@@ -165,5 +201,9 @@ func (server *LoginServer) ClientDisconnected(conn mnet.Client) {
 		}
 	}
 
+	delete(server.pinVerified, conn)
+	delete(server.pinChanging, conn)
+	delete(server.pending, conn)
+
 	conn.Cleanup()
 }
@@ -1,16 +1,21 @@
 package server
 
 import (
-	"crypto/sha512"
-	"encoding/hex"
+	"database/sql"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/ban"
 	"github.com/Hucaru/Valhalla/server/item"
 	"github.com/Hucaru/Valhalla/server/message"
+	"github.com/Hucaru/Valhalla/server/migration"
+	"github.com/Hucaru/Valhalla/server/password"
 	"github.com/Hucaru/Valhalla/server/player"
 )
 
@@ -20,7 +25,9 @@ func (server *LoginServer) HandleClientPacket(conn mnet.Client, reader mpacket.R
 	case opcode.RecvLoginRequest:
 		server.handleLoginRequest(conn, reader)
 	case opcode.RecvLoginCheckLogin:
-		server.handleGoodLogin(conn, reader)
+		server.handlePinOperation(conn, reader)
+	case opcode.RecvLoginRegisterPin:
+		server.handlePinRegister(conn, reader)
 	case opcode.RecvLoginWorldSelect:
 		server.handleWorldSelect(conn, reader)
 	case opcode.RecvLoginChannelSelect:
@@ -41,12 +48,15 @@ func (server *LoginServer) HandleClientPacket(conn mnet.Client, reader mpacket.R
 }
 func (server *LoginServer) handleLoginRequest(conn mnet.Client, reader mpacket.Reader) {
 	username := reader.ReadString(reader.ReadInt16())
-	password := reader.ReadString(reader.ReadInt16())
+	secret := reader.ReadString(reader.ReadInt16())
 
-	// hash the password, cba to salt atm
-	hasher := sha512.New()
-	hasher.Write([]byte(password))
-	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
+	ip := ban.IPFromAddr(conn.String())
+	now := time.Now()
+
+	if server.loginThrottled(username, ip, now) {
+		conn.Send(packetLoginResponce(0x0A, 0, 0, false, username, 0, 0))
+		return
+	}
 
 	var accountID int32
 	var user string
@@ -56,30 +66,69 @@ func (server *LoginServer) handleLoginRequest(conn mnet.Client, reader mpacket.R
 	var isBanned int
 	var adminLevel int
 
-	err := server.db.QueryRow("SELECT accountID, username, password, gender, isLogedIn, isBanned, adminLevel FROM accounts WHERE username=?", username).
-		Scan(&accountID, &user, &databasePassword, &gender, &isLogedIn, &isBanned, &adminLevel)
+	lookup := func() error {
+		return server.db.QueryRow("SELECT accountID, username, password, gender, isLogedIn, isBanned, adminLevel FROM accounts WHERE username=?", username).
+			Scan(&accountID, &user, &databasePassword, &gender, &isLogedIn, &isBanned, &adminLevel)
+	}
+
+	err := lookup()
+
+	if err == sql.ErrNoRows && server.autoRegister && server.registerOnLogin(username, secret, ip) {
+		err = lookup()
+	}
 
 	result := byte(0x00)
+	match, upgrade := password.Verify(secret, databasePassword)
+
+	banReason := byte(isBanned)
+	banEnd := int64(constant.BanPermanentFiletime)
+
+	if err == nil && match {
+		activeBan, banned, err := ban.Active(server.db, accountID, ip)
+
+		if err != nil {
+			log.Println("Unable to check bans for account", accountID, err)
+		} else if banned {
+			banReason = activeBan.Reason
+
+			if !activeBan.Permanent() {
+				banEnd = unixToFiletime(activeBan.End.Unix())
+			}
+		} else if ended, err := ban.Expire(server.db, accountID); err != nil {
+			log.Println("Unable to expire bans for account", accountID, err)
+		} else if ended {
+			banReason = 0
+		}
+	}
 
 	if err != nil {
 		result = 0x05
-	} else if hashedPassword != databasePassword {
+	} else if !match {
 		result = 0x04
+	} else if banReason > 0 {
+		result = 0x02
 	} else if isLogedIn {
 		result = 0x07
-	} else if isBanned > 0 {
-		result = 0x02
+	}
+
+	if result == 0x04 || result == 0x05 {
+		server.loginFailed(username, ip, now)
 	}
 
 	// Banned = 2, Deleted or Blocked = 3, Invalid Password = 4, Not Registered = 5, Sys Error = 6,
 	// Already online = 7, System error = 9, Too many requests = 10, Older than 20 = 11, Master cannot login on this IP = 13
 
 	if result <= 0x01 {
+		server.loginSucceeded(conn, username)
 		conn.SetLogedIn(true)
 		conn.SetGender(gender)
 		conn.SetAdminLevel(adminLevel)
 		conn.SetAccountID(accountID)
 
+		if upgrade {
+			server.upgradePasswordHash(accountID, secret)
+		}
+
 		_, err := server.db.Exec("UPDATE accounts set isLogedIn=1 WHERE accountID=?", accountID)
 
 		if err != nil {
@@ -89,10 +138,29 @@ func (server *LoginServer) handleLoginRequest(conn mnet.Client, reader mpacket.R
 		}
 	}
 
-	conn.Send(packetLoginResponce(result, accountID, gender, adminLevel > 0, username, isBanned))
+	conn.Send(packetLoginResponce(result, accountID, gender, adminLevel > 0, username, banReason, banEnd))
 }
 
-func (server *LoginServer) handleGoodLogin(conn mnet.Client, reader mpacket.Reader) {
+// unixToFiletime converts unix seconds to the windows file time the client shows dates with
+func unixToFiletime(t int64) int64 {
+	return (t + 11644473600) * 10000000
+}
+
+// upgradePasswordHash replaces a legacy or outdated stored hash now that the plain password is known
+func (server *LoginServer) upgradePasswordHash(accountID int32, secret string) {
+	hash, err := password.Hash(secret)
+
+	if err != nil {
+		log.Println("Unable to hash password for account", accountID, err)
+		return
+	}
+
+	if _, err := server.db.Exec("UPDATE accounts SET password=? WHERE accountID=?", hash, accountID); err != nil {
+		log.Println("Unable to upgrade password hash for account", accountID, err)
+	}
+}
+
+func (server *LoginServer) sendWorldList(conn mnet.Client) {
 	server.migrating[conn] = false
 	var username, password string
 
@@ -105,9 +173,11 @@ func (server *LoginServer) handleGoodLogin(conn mnet.Client, reader mpacket.Read
 		log.Println("handleCheckLogin database retrieval issue for accountID:", accountID, err)
 	}
 
-	const maxNumberOfWorlds = 14
-
 	for i := len(server.worlds) - 1; i > -1; i-- {
+		if server.worlds[i].name == "" {
+			continue
+		}
+
 		conn.Send(packetLoginWorldListing(byte(i), server.worlds[i]))
 	}
 
@@ -115,9 +185,19 @@ func (server *LoginServer) handleGoodLogin(conn mnet.Client, reader mpacket.Read
 }
 
 func (server *LoginServer) handleWorldSelect(conn mnet.Client, reader mpacket.Reader) {
-	conn.SetWorldID(reader.ReadByte())
+	if !server.pinPassed(conn) {
+		return
+	}
+
+	worldID := reader.ReadByte()
 	reader.ReadByte() // ?
 
+	if int(worldID) >= len(server.worlds) || server.worlds[worldID].name == "" {
+		return
+	}
+
+	conn.SetWorldID(worldID)
+
 	var warning, population byte = 0, 0
 
 	if conn.GetAdminLevel() < 1 { // gms are not restricted in any capacity
@@ -142,10 +222,15 @@ func (server *LoginServer) handleWorldSelect(conn mnet.Client, reader mpacket.Re
 }
 
 func (server *LoginServer) handleChannelSelect(conn mnet.Client, reader mpacket.Reader) {
+	if !server.pinPassed(conn) {
+		return
+	}
+
 	selectedWorld := reader.ReadByte()   // world
 	conn.SetChannelID(reader.ReadByte()) // Channel
 
-	if server.worlds[selectedWorld].channels[conn.GetChannelID()].maxPop == 0 {
+	if int(selectedWorld) >= len(server.worlds) || int(conn.GetChannelID()) >= len(server.worlds[selectedWorld].channels) ||
+		server.worlds[selectedWorld].channels[conn.GetChannelID()].maxPop == 0 {
 		conn.Send(message.PacketMessageDialogueBox("Channel currently unavailable"))
 		return
 	}
@@ -171,6 +256,10 @@ func (server *LoginServer) handleNameCheck(conn mnet.Client, reader mpacket.Read
 }
 
 func (server *LoginServer) handleNewCharacter(conn mnet.Client, reader mpacket.Reader) {
+	if !server.pinPassed(conn) {
+		return
+	}
+
 	name := reader.ReadString(reader.ReadInt16())
 	face := reader.ReadInt32()
 	hair := reader.ReadInt32()
@@ -186,8 +275,6 @@ func (server *LoginServer) handleNewCharacter(conn mnet.Client, reader mpacket.R
 	intelligence := reader.ReadByte()
 	luk := reader.ReadByte()
 
-	// Add str, dex, int, luk validation (check to see if client generates a constant sum)
-
 	var counter int
 
 	err := server.db.QueryRow("SELECT count(*) FROM characters where name=? and worldID=?", name, conn.GetWorldID()).Scan(&counter)
@@ -196,15 +283,6 @@ func (server *LoginServer) handleNewCharacter(conn mnet.Client, reader mpacket.R
 		panic(err)
 	}
 
-	allowedEyes := []int32{20000, 20001, 20002, 21000, 21001, 21002, 20100, 20401, 20402, 21700, 21201, 21002}
-	allowedHair := []int32{30000, 30020, 30030, 31000, 31040, 31050}
-	allowedHairColour := []int32{0, 7, 3, 2}
-	allowedBottom := []int32{1060002, 1060006, 1061002, 1061008, 1062115}
-	allowedTop := []int32{1040002, 1040006, 1040010, 1041002, 1041006, 1041010, 1041011, 1042167}
-	allowedShoes := []int32{1072001, 1072005, 1072037, 1072038, 1072383}
-	allowedWeapons := []int32{1302000, 1322005, 1312004, 1442079}
-	allowedSkinColour := []int32{0, 1, 2, 3}
-
 	inSlice := func(val int32, s []int32) bool {
 		for _, b := range s {
 			if b == val {
@@ -214,9 +292,25 @@ func (server *LoginServer) handleNewCharacter(conn mnet.Client, reader mpacket.R
 		return false
 	}
 
-	valid := inSlice(face, allowedEyes) && inSlice(hair, allowedHair) && inSlice(hairColour, allowedHairColour) &&
-		inSlice(bottom, allowedBottom) && inSlice(top, allowedTop) && inSlice(shoes, allowedShoes) &&
-		inSlice(weapon, allowedWeapons) && inSlice(skin, allowedSkinColour) && (counter == 0)
+	validStat := func(stat byte) bool {
+		return stat >= constant.CharacterCreationStatMin && stat <= constant.CharacterCreationStatMax
+	}
+
+	valid := counter == 0 && validStat(str) && validStat(dex) && validStat(intelligence) && validStat(luk) &&
+		int(str)+int(dex)+int(intelligence)+int(luk) == constant.CharacterCreationStatTotal
+
+	if info, err := nx.GetMakeCharInfo(conn.GetGender()); err == nil {
+		valid = valid && inSlice(face, info.Faces) && inSlice(hair, info.Hairs) && inSlice(hairColour, info.HairColours) &&
+			inSlice(skin, info.Skins) && inSlice(top, info.Tops) && inSlice(bottom, info.Bottoms) &&
+			inSlice(shoes, info.Shoes) && inSlice(weapon, info.Weapons)
+	} else {
+		log.Println(err)
+		valid = false
+	}
+
+	if !valid && counter == 0 {
+		log.Println("Account", conn.GetAccountID(), "sent character creation options the client does not offer")
+	}
 
 	newCharacter := player.Data{}
 
@@ -300,6 +394,10 @@ func (server *LoginServer) handleNewCharacter(conn mnet.Client, reader mpacket.R
 }
 
 func (server *LoginServer) handleDeleteCharacter(conn mnet.Client, reader mpacket.Reader) {
+	if !server.pinPassed(conn) {
+		return
+	}
+
 	dob := reader.ReadInt32()
 	charID := reader.ReadInt32()
 
@@ -307,10 +405,15 @@ func (server *LoginServer) handleDeleteCharacter(conn mnet.Client, reader mpacke
 	var charCount int
 
 	err := server.db.QueryRow("SELECT dob FROM accounts where accountID=?", conn.GetAccountID()).Scan(&storedDob)
-	err = server.db.QueryRow("SELECT count(*) FROM characters where accountID=? AND id=?", conn.GetAccountID(), charID).Scan(&charCount)
+
+	if err == nil {
+		err = server.db.QueryRow("SELECT count(*) FROM characters where accountID=? AND id=? AND deletedAt IS NULL", conn.GetAccountID(), charID).Scan(&charCount)
+	}
 
 	if err != nil {
-		panic(err)
+		log.Println("Unable to check character", charID, "for deletion:", err)
+		conn.Send(packetLoginDeleteCharacter(charID, false, false))
+		return
 	}
 
 	hacking := false
@@ -319,43 +422,58 @@ func (server *LoginServer) handleDeleteCharacter(conn mnet.Client, reader mpacke
 	if charCount != 1 {
 		log.Println(conn.GetAccountID(), "attempted to delete a character they do not own:", charID)
 		hacking = true
-	}
-
-	if dob == storedDob {
-		records, err := server.db.Query("DELETE FROM characters where id=?", charID)
-
-		defer records.Close()
-
-		if err != nil {
-			log.Println(err)
-			return
+	} else if storedDob == 0 {
+		log.Println("Account", conn.GetAccountID(), "has no dob set, refusing to delete character", charID)
+	} else if dob == storedDob {
+		if err := player.SoftDelete(server.db, charID); err != nil {
+			log.Println("Unable to delete character", charID, err)
+		} else {
+			log.Println("Account", conn.GetAccountID(), "deleted character", charID)
+			deleted = true
 		}
-
-		deleted = true
 	}
 
 	conn.Send(packetLoginDeleteCharacter(charID, deleted, hacking))
 }
 
 func (server *LoginServer) handleSelectCharacter(conn mnet.Client, reader mpacket.Reader) {
+	if !server.pinPassed(conn) {
+		return
+	}
+
 	charID := reader.ReadInt32()
 
 	var charCount int
 
-	err := server.db.QueryRow("SELECT count(*) FROM characters where accountID=? AND id=?", conn.GetAccountID(), charID).Scan(&charCount)
+	err := server.db.QueryRow("SELECT count(*) FROM characters where accountID=? AND id=? AND deletedAt IS NULL", conn.GetAccountID(), charID).Scan(&charCount)
 
 	if err != nil {
 		panic(err)
 	}
 
 	if charCount == 1 {
-		channel := server.worlds[conn.GetWorldID()].channels[conn.GetChannelID()]
-		_, err := server.db.Exec("UPDATE characters SET migrationID=? WHERE id=?", conn.GetChannelID(), charID)
+		if int(conn.GetWorldID()) >= len(server.worlds) {
+			return
+		}
+
+		world := server.worlds[conn.GetWorldID()]
+
+		if world.conn == nil || int(conn.GetChannelID()) >= len(world.channels) {
+			return
+		}
+
+		channel := world.channels[conn.GetChannelID()]
+
+		ticket := migration.New(conn.GetAccountID(), charID, conn.GetChannelID(), ban.IPFromAddr(conn.String()))
+
+		_, err = server.db.Exec("UPDATE characters SET migrationID=? WHERE id=?", conn.GetChannelID(), charID)
 
 		if err != nil {
 			panic(err)
 		}
 
+		world.conn.Send(ticket.Packet())
+
 		server.migrating[conn] = true
 
 		conn.Send(packetLoginMigrateClient(channel.ip, channel.port, charID))
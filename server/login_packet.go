@@ -8,7 +8,7 @@ import (
 	"github.com/Hucaru/Valhalla/server/player"
 )
 
-func packetLoginResponce(result byte, userID int32, gender byte, isAdmin bool, username string, isBanned int) mpacket.Packet {
+func packetLoginResponce(result byte, userID int32, gender byte, isAdmin bool, username string, banReason byte, banEnd int64) mpacket.Packet {
 	pac := mpacket.CreateWithOpcode(opcode.SendLoginResponce)
 	pac.WriteByte(result)
 	pac.WriteByte(0x00)
@@ -22,8 +22,8 @@ func packetLoginResponce(result byte, userID int32, gender byte, isAdmin bool, u
 		pac.WriteByte(0x01)
 		pac.WriteString(username)
 	} else if result == 0x02 {
-		pac.WriteByte(byte(isBanned))
-		pac.WriteInt64(0) // Expire time, for now let set this to epoch
+		pac.WriteByte(banReason)
+		pac.WriteInt64(banEnd)
 	}
 
 	pac.WriteInt64(0)
@@ -169,11 +169,17 @@ func loginWritePlayerCharacter(pac *mpacket.Packet, pos int32, char player.Data)
 }
 
 func packetLoginWorldListing(worldIndex byte, w world) mpacket.Packet {
+	ribbon, message := w.ribbon, w.message
+
+	if w.conn == nil {
+		ribbon, message = 0, "This world is offline"
+	}
+
 	pac := mpacket.CreateWithOpcode(opcode.SendLoginWorldList)
 	pac.WriteByte(worldIndex) // world id
 	pac.WriteString(w.name)   // World name -
-	pac.WriteByte(w.ribbon)   // Ribbon on world - 0 = normal, 1 = event, 2 = new, 3 = hot
-	pac.WriteString(w.message)
+	pac.WriteByte(ribbon)     // Ribbon on world - 0 = normal, 1 = event, 2 = new, 3 = hot
+	pac.WriteString(message)
 	pac.WriteByte(0)                     // ? exp event notification?
 	pac.WriteByte(byte(len(w.channels))) // number of channels
 
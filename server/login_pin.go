@@ -0,0 +1,214 @@
+package server
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/password"
+)
+
+// Pin operations the client can be asked to show
+const (
+	pinAccepted = 0
+	pinRegister = 1
+	pinInvalid  = 2
+	pinFailed   = 3
+	pinRequest  = 4
+)
+
+// SetPinEnabled requires accounts to register and enter a pin before they can select a world
+func (server *LoginServer) SetPinEnabled(enabled bool) {
+	server.pinEnabled = enabled
+}
+
+// pinPassed returns true if the connection does not need to enter a pin or has entered it correctly
+func (server LoginServer) pinPassed(conn mnet.Client) bool {
+	return conn.GetLogedIn() && (!server.pinEnabled || server.pinVerified[conn])
+}
+
+func validPin(pin string) bool {
+	if len(pin) != constant.LoginPinLength {
+		return false
+	}
+
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (server *LoginServer) storedPin(accountID int32) (string, error) {
+	var pin sql.NullString
+	err := server.db.QueryRow("SELECT pin FROM accounts WHERE accountID=?", accountID).Scan(&pin)
+
+	return pin.String, err
+}
+
+func (server *LoginServer) storePin(accountID int32, pin string) error {
+	hash, err := password.Hash(pin)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = server.db.Exec("UPDATE accounts SET pin=? WHERE accountID=?", hash, accountID)
+
+	return err
+}
+
+func pinAttemptKey(accountID int32) string {
+	return "pin:" + strconv.Itoa(int(accountID))
+}
+
+// checkPin against the stored hash, failures are kept per account with the login attempts so reconnecting does not reset them
+func (server *LoginServer) checkPin(conn mnet.Client, pin string) bool {
+	key := pinAttemptKey(conn.GetAccountID())
+	now := time.Now()
+
+	if v, ok := server.attempts[key]; ok && now.Before(v.lockedUntil) {
+		conn.Send(packetLoginPinOperation(pinFailed))
+		conn.Close()
+		return false
+	}
+
+	stored, err := server.storedPin(conn.GetAccountID())
+
+	if err != nil {
+		log.Println("Unable to get pin for account", conn.GetAccountID(), err)
+		conn.Send(packetLoginPinOperation(pinFailed))
+		return false
+	}
+
+	if match, upgrade := password.Verify(pin, stored); stored != "" && match {
+		delete(server.attempts, key)
+
+		if upgrade {
+			server.storePin(conn.GetAccountID(), pin)
+		}
+
+		return true
+	}
+
+	v, ok := server.attempts[key]
+
+	if !ok {
+		v = &loginAttempts{}
+		server.attempts[key] = v
+	}
+
+	v.failures++
+	v.last = now
+
+	if v.failures >= constant.LoginPinMaxAttempts {
+		v.lockedUntil = now.Add(time.Second * constant.LoginLockoutTime)
+		v.failures = 0
+
+		log.Println("Account", conn.GetAccountID(), "entered too many wrong pins from", conn)
+		conn.Send(packetLoginPinOperation(pinFailed))
+		conn.Close()
+		return false
+	}
+
+	conn.Send(packetLoginPinOperation(pinInvalid))
+
+	return false
+}
+
+// handlePinOperation is sent after a successful login and to enter or change a pin
+func (server *LoginServer) handlePinOperation(conn mnet.Client, reader mpacket.Reader) {
+	if !conn.GetLogedIn() {
+		return
+	}
+
+	if !server.pinEnabled {
+		server.sendWorldList(conn)
+		return
+	}
+
+	op := reader.ReadByte()
+	step := reader.ReadByte()
+
+	switch {
+	case op == 1 && step == 1: // login completed, pin is wanted
+		stored, err := server.storedPin(conn.GetAccountID())
+
+		if err != nil {
+			log.Println("Unable to get pin for account", conn.GetAccountID(), err)
+			conn.Send(packetLoginPinOperation(pinFailed))
+		} else if stored == "" {
+			conn.Send(packetLoginPinOperation(pinRegister))
+		} else {
+			conn.Send(packetLoginPinOperation(pinRequest))
+		}
+	case op == 1 && step == 0: // pin entered
+		if server.checkPin(conn, reader.ReadString(reader.ReadInt16())) {
+			server.pinVerified[conn] = true
+			conn.Send(packetLoginPinOperation(pinAccepted))
+			server.sendWorldList(conn)
+		}
+	case op == 2 && step == 0: // change pin, the current one has to be entered first
+		if server.checkPin(conn, reader.ReadString(reader.ReadInt16())) {
+			server.pinChanging[conn] = true
+			conn.Send(packetLoginPinOperation(pinRegister))
+		}
+	default: // cancelled
+	}
+}
+
+// handlePinRegister stores a new pin for accounts without one or that have just entered their current pin
+func (server *LoginServer) handlePinRegister(conn mnet.Client, reader mpacket.Reader) {
+	if !conn.GetLogedIn() || !server.pinEnabled || reader.ReadByte() == 0 {
+		return
+	}
+
+	pin := reader.ReadString(reader.ReadInt16())
+
+	stored, err := server.storedPin(conn.GetAccountID())
+
+	if err != nil {
+		log.Println("Unable to get pin for account", conn.GetAccountID(), err)
+		return
+	}
+
+	if stored != "" && !server.pinChanging[conn] {
+		log.Println("Account", conn.GetAccountID(), "tried to replace their pin without entering it")
+		return
+	}
+
+	if !validPin(pin) {
+		conn.Send(packetLoginPinOperation(pinRegister))
+		return
+	}
+
+	if err := server.storePin(conn.GetAccountID(), pin); err != nil {
+		log.Println("Unable to set pin for account", conn.GetAccountID(), err)
+		conn.Send(packetLoginPinOperation(pinFailed))
+		return
+	}
+
+	delete(server.pinChanging, conn)
+	conn.Send(packetLoginPinAssigned())
+}
+
+func packetLoginPinOperation(mode byte) mpacket.Packet {
+	pac := mpacket.CreateWithOpcode(opcode.SendLoginPinOperation)
+	pac.WriteByte(mode)
+
+	return pac
+}
+
+func packetLoginPinAssigned() mpacket.Packet {
+	pac := mpacket.CreateWithOpcode(opcode.SendLoginPinAssigned)
+	pac.WriteByte(0)
+
+	return pac
+}
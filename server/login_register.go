@@ -0,0 +1,37 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Hucaru/Valhalla/server/account"
+)
+
+// SetAutoRegister creates accounts for unknown usernames on their first login attempt
+func (server *LoginServer) SetAutoRegister(enabled bool) {
+	server.autoRegister = enabled
+}
+
+// RegistrationHandler for the http registration endpoint
+func (server *LoginServer) RegistrationHandler() http.Handler {
+	return account.Handler(server.db)
+}
+
+// registerOnLogin returns true if an account was created for the username, the account has no dob so characters
+// cannot be deleted until one is set through the registration endpoint
+func (server *LoginServer) registerOnLogin(username, secret, ip string) bool {
+	id, err := account.Register(server.db, account.Registration{
+		Username: username,
+		Password: secret,
+		IP:       ip,
+	})
+
+	if err != nil {
+		log.Println("Unable to auto register", username, "from", ip+":", err)
+		return false
+	}
+
+	log.Println("Auto registered account", id, "for", username, "from", ip)
+
+	return true
+}
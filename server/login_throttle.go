@@ -0,0 +1,129 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/server/ban"
+)
+
+// loginAttempts failed against an account or from an ip
+type loginAttempts struct {
+	failures    int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// backoff before another attempt is allowed, doubling with each failure
+func (a loginAttempts) backoff() time.Duration {
+	if a.failures == 0 {
+		return 0
+	}
+
+	wait := time.Second * constant.LoginBackoffBase << uint(a.failures-1)
+
+	if max := time.Second * constant.LoginBackoffMax; wait > max || wait <= 0 {
+		wait = max
+	}
+
+	return wait
+}
+
+func loginAttemptKeys(username, ip string) []string {
+	return []string{"account:" + username, "ip:" + ip}
+}
+
+// ClientConnected is refused if the ip already has too many connections that have not logged in
+func (server *LoginServer) ClientConnected(conn mnet.Client) {
+	ip := ban.IPFromAddr(conn.String())
+	count := 0
+
+	for v := range server.pending {
+		if ban.IPFromAddr(v.String()) == ip {
+			count++
+		}
+	}
+
+	if count >= constant.LoginMaxPendingPerIP {
+		log.Println("Refused connection from", conn, "as the ip has too many connections that have not logged in")
+		conn.Close()
+		return
+	}
+
+	server.pending[conn] = time.Now()
+}
+
+// updateThrottle closes connections that have not logged in within the idle timeout and forgets old failed attempts
+func (server *LoginServer) updateThrottle(t time.Time) {
+	for conn, connected := range server.pending {
+		if conn.GetLogedIn() {
+			delete(server.pending, conn)
+		} else if t.Sub(connected) > time.Second*constant.LoginIdleTimeout {
+			log.Println("Closing idle connection from", conn)
+			delete(server.pending, conn)
+			conn.Close()
+		}
+	}
+
+	for key, v := range server.attempts {
+		if t.After(v.lockedUntil) && t.Sub(v.last) > time.Second*constant.LoginAttemptWindow {
+			delete(server.attempts, key)
+		}
+	}
+}
+
+// loginThrottled returns true if the account or ip is locked out or has to wait before trying again
+func (server *LoginServer) loginThrottled(username, ip string, t time.Time) bool {
+	for _, key := range loginAttemptKeys(username, ip) {
+		v, ok := server.attempts[key]
+
+		if !ok {
+			continue
+		}
+
+		if t.Before(v.lockedUntil) || t.Before(v.last.Add(v.backoff())) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loginFailed against the account from the ip, locking both out after too many failures
+func (server *LoginServer) loginFailed(username, ip string, t time.Time) {
+	for _, key := range loginAttemptKeys(username, ip) {
+		v, ok := server.attempts[key]
+
+		if !ok {
+			v = &loginAttempts{}
+			server.attempts[key] = v
+		}
+
+		v.failures++
+		v.last = t
+
+		if v.failures < constant.LoginMaxFailures {
+			continue
+		}
+
+		v.lockedUntil = t.Add(time.Second * constant.LoginLockoutTime)
+		v.failures = 0
+
+		log.Println("Locked out", key, "after", constant.LoginMaxFailures, "failed logins")
+
+		_, err := server.db.Exec("INSERT INTO login_lockouts(lockKey, username, ip, lockedAt, lockedUntil) VALUES(?,?,?,?,?)",
+			key, username, ip, t.Unix(), v.lockedUntil.Unix())
+
+		if err != nil {
+			log.Println("Unable to record lockout of", key, err)
+		}
+	}
+}
+
+// loginSucceeded clears the account's failures, the ip's are left to decay so one good account cannot reset guessing at others
+func (server *LoginServer) loginSucceeded(conn mnet.Client, username string) {
+	delete(server.attempts, loginAttemptKeys(username, "")[0])
+	delete(server.pending, conn)
+}
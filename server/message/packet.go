@@ -124,6 +124,14 @@ func PacketCannotChangeChannel() mpacket.Packet {
 	return p
 }
 
+// PacketCannotEnterCashShop - red text
+func PacketCannotEnterCashShop() mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelChangeServer)
+	p.WriteByte(2)
+
+	return p
+}
+
 // PacketMessageWhiteBar - white bar message, is this gm chat messages?
 func PacketMessageWhiteBar(msg string) mpacket.Packet {
 	p := mpacket.CreateWithOpcode(opcode.SendChannelBroadcastMessage)
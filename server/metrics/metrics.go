@@ -20,7 +20,8 @@ var (
 	Counters = make(map[string]*prometheus.CounterVec)
 )
 
-func init() {
+// Serve metrics on Port in the background
+func Serve() {
 	go func() {
 		http.Handle("/metrics", promhttp.HandlerFor(
 			prometheus.DefaultGatherer,
@@ -0,0 +1,150 @@
+// Package migration issues the tickets that let a client move between the login, channel and cash shop servers
+package migration
+
+import (
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/constant/opcode"
+	"github.com/Hucaru/Valhalla/mnet"
+	"github.com/Hucaru/Valhalla/mpacket"
+)
+
+// Ticket allowing a character to connect to a channel or the cash shop. The client only sends its character id when
+// it connects, so a ticket is matched on the character, target and ip rather than on a secret the client holds.
+type Ticket struct {
+	AccountID   int32
+	CharacterID int32
+	Target      byte // channel id or constant.CashShopID
+	IP          string
+	Expires     time.Time
+}
+
+// New ticket for the character to connect to target from ip
+func New(accountID, characterID int32, target byte, ip string) Ticket {
+	return Ticket{
+		AccountID:   accountID,
+		CharacterID: characterID,
+		Target:      target,
+		IP:          ip,
+		Expires:     time.Now().Add(time.Second * constant.MigrationTicketExpiry),
+	}
+}
+
+// Packet handing the ticket over to the world server
+func (t Ticket) Packet() mpacket.Packet {
+	p := mpacket.CreateInternal(opcode.MigrationTicket)
+	p.WriteInt32(t.AccountID)
+	p.WriteInt32(t.CharacterID)
+	p.WriteByte(t.Target)
+	p.WriteString(t.IP)
+	p.WriteInt64(t.Expires.Unix())
+
+	return p
+}
+
+// Read a ticket written by Packet
+func Read(reader *mpacket.Reader) Ticket {
+	return Ticket{
+		AccountID:   reader.ReadInt32(),
+		CharacterID: reader.ReadInt32(),
+		Target:      reader.ReadByte(),
+		IP:          reader.ReadString(reader.ReadInt16()),
+		Expires:     time.Unix(reader.ReadInt64(), 0),
+	}
+}
+
+// Tickets waiting to be redeemed, keyed by character id
+type Tickets map[int32]Ticket
+
+// Add a ticket, replacing any earlier one for the character and dropping any that have expired
+func (tickets Tickets) Add(t Ticket) {
+	now := time.Now()
+
+	for id, v := range tickets {
+		if now.After(v.Expires) {
+			delete(tickets, id)
+		}
+	}
+
+	tickets[t.CharacterID] = t
+}
+
+// Redeem the character's ticket, it can only be used once and must match target and ip. A ticket that does not match
+// is kept so another connection cannot use up the character's ticket.
+func (tickets Tickets) Redeem(characterID int32, target byte, ip string) (Ticket, bool) {
+	t, ok := tickets[characterID]
+
+	if !ok {
+		return t, false
+	}
+
+	if time.Now().After(t.Expires) {
+		delete(tickets, characterID)
+		return t, false
+	}
+
+	if t.Target != target || t.IP != ip {
+		return t, false
+	}
+
+	delete(tickets, characterID)
+
+	return t, true
+}
+
+// Waiting connections that arrived before their ticket, keyed by character id. Tickets travel from the login or
+// channel server through the world server, so a client can get to the target first.
+type Waiting map[int32]waiter
+
+type waiter struct {
+	conn  mnet.Client
+	until time.Time
+}
+
+// Hold the connection until the character's ticket arrives or constant.MigrationTicketWait passes. Only the first
+// connection for a character is held so a later one cannot push out the real client, false is returned if the
+// connection was not held.
+func (waiting Waiting) Hold(characterID int32, conn mnet.Client) bool {
+	now := time.Now()
+
+	for id, v := range waiting {
+		if now.After(v.until) {
+			delete(waiting, id)
+		}
+	}
+
+	if _, ok := waiting[characterID]; ok {
+		return false
+	}
+
+	waiting[characterID] = waiter{conn: conn, until: now.Add(time.Second * constant.MigrationTicketWait)}
+
+	return true
+}
+
+// Release the connection held for the character now that its ticket has arrived
+func (waiting Waiting) Release(characterID int32) (mnet.Client, bool) {
+	v, ok := waiting[characterID]
+
+	if !ok {
+		return nil, false
+	}
+
+	delete(waiting, characterID)
+
+	if time.Now().After(v.until) {
+		return nil, false
+	}
+
+	return v.conn, true
+}
+
+// Remove a connection that has closed while waiting
+func (waiting Waiting) Remove(conn mnet.Client) {
+	for id, v := range waiting {
+		if v.conn == conn {
+			delete(waiting, id)
+		}
+	}
+}
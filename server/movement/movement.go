@@ -81,7 +81,7 @@ func ParseMovement(reader mpacket.Reader) (Data, Frag) {
 
 	mData.frags = make([]Frag, nFrags)
 
-	final := Frag{}
+	final := Frag{x: mData.origX, y: mData.origY}
 
 	for i := byte(0); i < nFrags; i++ {
 		frag := Frag{posSet: false}
@@ -101,6 +101,7 @@ func ParseMovement(reader mpacket.Reader) (Data, Frag) {
 			frag.foothold = reader.ReadInt16()
 			frag.stance = reader.ReadByte()
 			frag.duration = reader.ReadInt16()
+			frag.posSet = true
 
 		case movementType.jump:
 			fallthrough
@@ -134,6 +135,7 @@ func ParseMovement(reader mpacket.Reader) (Data, Frag) {
 			frag.foothold = reader.ReadInt16()
 			frag.stance = reader.ReadByte()
 			frag.duration = reader.ReadInt16()
+			frag.posSet = true
 
 		case movementType.falling:
 			reader.ReadByte() // what is this
@@ -144,9 +146,13 @@ func ParseMovement(reader mpacket.Reader) (Data, Frag) {
 			frag.duration = reader.ReadInt16()
 		}
 
-		final.x = frag.x
-		final.y = frag.y
-		final.foothold = frag.foothold
+		// Jumps only carry velocity so the last known position is kept
+		if frag.posSet {
+			final.x = frag.x
+			final.y = frag.y
+			final.foothold = frag.foothold
+		}
+
 		final.stance = frag.stance
 
 		mData.frags[i] = frag
@@ -212,16 +218,6 @@ func GenerateMovementBytes(moveData Data) mpacket.Packet {
 	return p
 }
 
-type player interface {
-}
-
-// ValidateChar movement
-func (data Data) ValidateChar(player player) bool {
-	// run through the movement data and make sure characters are not moving too fast (going to have to take into account gear and buffs "-_- )
-
-	return true
-}
-
 type mob interface {
 }
 
@@ -0,0 +1,179 @@
+package movement
+
+import (
+	"math"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/pos"
+)
+
+// Violation found when replaying a movement
+type Violation byte
+
+// Violations that can be found
+const (
+	None Violation = iota
+	Teleport
+	Speed
+	Fly
+)
+
+func (v Violation) String() string {
+	switch v {
+	case Teleport:
+		return "teleport"
+	case Speed:
+		return "speed"
+	case Fly:
+		return "fly"
+	}
+
+	return "none"
+}
+
+type player interface {
+	Pos() pos.Data
+	Speed() int16
+	Jump() int16
+	Admin() bool
+	LastMoveTime() time.Time
+}
+
+// ValidateChar replays the movement fragments from the player's last accepted position against the footholds of the
+// field and the player's speed and jump, jumping and movement skills are refused in fields that do not allow them
+func (data Data) ValidateChar(plr player, footholds []nx.Foothold, swim, jump, movementSkills bool) Violation {
+	maxVX := float64(constant.MovementBaseSpeed) * float64(plr.Speed()) / 100
+	maxVY := float64(constant.MovementBaseJump) * float64(plr.Jump()) / 100
+	tolerance := float64(constant.MovementTolerance)
+
+	// The client can only have travelled so far since the last movement the server accepted, a player that stood still
+	// for a long time has not earned a longer jump
+	elapsed := math.Min(time.Since(plr.LastMoveTime()).Seconds(), constant.MovementMaxElapsed)
+
+	if last := plr.Pos(); distance(last.X(), last.Y(), data.origX, data.origY) > reach(maxVX, elapsed, tolerance) {
+		if !plr.Admin() {
+			return Teleport
+		}
+	}
+
+	curX, curY := data.origX, data.origY
+
+	// Jumps only carry a velocity so how far the player can have moved is built up over every fragment since the last
+	// one that set a position
+	var reachX, reachUp, reachDown float64
+
+	for _, frag := range data.frags {
+		dt := float64(frag.duration) / 1000
+
+		switch frag.mType {
+		case movementType.jump, movementType.jumpKb:
+			reachX += maxVX * dt
+			reachUp += maxVY * dt
+			reachDown += constant.MovementMaxFallSpeed * dt
+		case movementType.flashJump:
+			reachX += constant.MovementFlashJumpSpeed * dt
+			reachUp += maxVY * dt
+			reachDown += constant.MovementMaxFallSpeed * dt
+		default:
+			reachX += maxVX * dt
+			reachUp += constant.MovementMaxFallSpeed * dt
+			reachDown += constant.MovementMaxFallSpeed * dt
+		}
+
+		switch frag.mType {
+		case movementType.immediate:
+			if !plr.Admin() {
+				return Teleport
+			}
+		case movementType.teleport, movementType.assaulter:
+			if !movementSkills && !plr.Admin() {
+				return Teleport
+			}
+
+			if distance(curX, curY, frag.x, frag.y) > constant.MovementSkillRange {
+				return Teleport
+			}
+		case movementType.normalMovement, movementType.normalMovement2, movementType.normalMovement3:
+			if math.Abs(float64(frag.vx)) > maxVX*1.2+tolerance {
+				return Speed
+			}
+
+			if math.Abs(float64(frag.x-curX)) > reachX+tolerance {
+				return Speed
+			}
+
+			if dy := float64(frag.y - curY); -dy > reachUp+tolerance || dy > reachDown+tolerance {
+				return Fly
+			}
+
+			if !swim && frag.foothold != 0 && len(footholds) > 0 && !onFoothold(footholds, frag.foothold, frag.x, frag.y) {
+				return Fly
+			}
+		case movementType.jump, movementType.jumpKb:
+			if !jump && !plr.Admin() {
+				return Fly
+			}
+
+			if !swim && float64(-frag.vy) > maxVY*1.2+tolerance {
+				return Fly
+			}
+		case movementType.flashJump:
+			if math.Abs(float64(frag.vx)) > constant.MovementFlashJumpSpeed*1.2+tolerance {
+				return Speed
+			}
+
+			if !swim && float64(-frag.vy) > maxVY*1.2+tolerance {
+				return Fly
+			}
+		}
+
+		if frag.posSet {
+			curX, curY = frag.x, frag.y
+			reachX, reachUp, reachDown = 0, 0, 0
+		}
+	}
+
+	return None
+}
+
+func reach(speed, seconds, tolerance float64) float64 {
+	return speed*seconds + tolerance
+}
+
+func distance(x1, y1, x2, y2 int16) float64 {
+	dx := float64(x1) - float64(x2)
+	dy := float64(y1) - float64(y2)
+
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// onFoothold checks the position lies on the foothold with the given id
+func onFoothold(footholds []nx.Foothold, id, x, y int16) bool {
+	tolerance := float64(constant.MovementTolerance)
+
+	for _, fh := range footholds {
+		if fh.ID != id {
+			continue
+		}
+
+		// Walls have no surface to stand on so cannot be checked
+		if fh.X1 == fh.X2 {
+			return true
+		}
+
+		minX, maxX := math.Min(float64(fh.X1), float64(fh.X2)), math.Max(float64(fh.X1), float64(fh.X2))
+
+		if float64(x) < minX-tolerance || float64(x) > maxX+tolerance {
+			return false
+		}
+
+		slope := float64(fh.Y2-fh.Y1) / float64(fh.X2-fh.X1)
+		surfaceY := float64(fh.Y1) + slope*(float64(x)-float64(fh.X1))
+
+		return math.Abs(float64(y)-surfaceY) <= tolerance
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package movement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/pos"
+)
+
+type testPlayer struct {
+	pos      pos.Data
+	lastMove time.Time
+}
+
+func (p testPlayer) Pos() pos.Data           { return p.pos }
+func (p testPlayer) Speed() int16            { return 100 }
+func (p testPlayer) Jump() int16             { return 100 }
+func (p testPlayer) Admin() bool             { return false }
+func (p testPlayer) LastMoveTime() time.Time { return p.lastMove }
+
+func writeJump(p *mpacket.Packet, mType byte, vx, vy, duration int16) {
+	p.WriteByte(mType)
+	p.WriteInt16(vx)
+	p.WriteInt16(vy)
+	p.WriteByte(0) // stance
+	p.WriteInt16(duration)
+}
+
+func writeNormal(p *mpacket.Packet, x, y, duration int16) {
+	p.WriteByte(movementType.normalMovement)
+	p.WriteInt16(x)
+	p.WriteInt16(y)
+	p.WriteInt16(0) // vx
+	p.WriteInt16(0) // vy
+	p.WriteInt16(0) // foothold
+	p.WriteByte(0)  // stance
+	p.WriteInt16(duration)
+}
+
+func replay(t *testing.T, p mpacket.Packet) Violation {
+	t.Helper()
+
+	reader := mpacket.NewReader(&p, 0)
+	data, _ := ParseMovement(reader)
+	plr := testPlayer{pos: pos.New(0, 0, 0), lastMove: time.Now().Add(-time.Second)}
+
+	return data.ValidateChar(plr, nil, false, true, true)
+}
+
+func movementPacket(nFrags byte) mpacket.Packet {
+	p := mpacket.NewPacket()
+	p.WriteInt16(0) // origin x
+	p.WriteInt16(0) // origin y
+	p.WriteByte(nFrags)
+
+	return p
+}
+
+func TestJumpThenMove(t *testing.T) {
+	p := movementPacket(2)
+	writeJump(&p, movementType.jump, 125, -555, 600)
+	writeNormal(&p, 100, -20, 100)
+
+	if v := replay(t, p); v != None {
+		t.Errorf("jump then move = %v, want none", v)
+	}
+}
+
+func TestJumpThenMoveTooFar(t *testing.T) {
+	p := movementPacket(2)
+	writeJump(&p, movementType.jump, 125, -555, 600)
+	writeNormal(&p, 300, 0, 100)
+
+	if v := replay(t, p); v != Speed {
+		t.Errorf("jump then move too far = %v, want speed", v)
+	}
+}
+
+func TestFlashJumpThenMove(t *testing.T) {
+	p := movementPacket(2)
+	writeJump(&p, movementType.flashJump, 600, -200, 500)
+	writeNormal(&p, 300, 0, 100)
+
+	if v := replay(t, p); v != None {
+		t.Errorf("flash jump then move = %v, want none", v)
+	}
+}
+
+func TestFlashJumpTooFast(t *testing.T) {
+	p := movementPacket(1)
+	writeJump(&p, movementType.flashJump, 2000, -200, 500)
+
+	if v := replay(t, p); v != Speed {
+		t.Errorf("fast flash jump = %v, want speed", v)
+	}
+}
+
+func TestJumpInNoJumpField(t *testing.T) {
+	p := movementPacket(1)
+	writeJump(&p, movementType.jump, 0, -555, 600)
+
+	reader := mpacket.NewReader(&p, 0)
+	data, _ := ParseMovement(reader)
+	plr := testPlayer{pos: pos.New(0, 0, 0), lastMove: time.Now().Add(-time.Second)}
+
+	if v := data.ValidateChar(plr, nil, false, false, true); v != Fly {
+		t.Errorf("jump in no jump field = %v, want fly", v)
+	}
+}
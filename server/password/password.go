@@ -0,0 +1,87 @@
+// Package password hashes account secrets. Hashes are encoded with the algorithm, its parameters and the salt so the
+// algorithm can be changed without invalidating stored hashes.
+package password
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher creates and checks encoded hashes for one algorithm
+type Hasher interface {
+	// Hash the secret with a new random salt
+	Hash(secret string) (string, error)
+	// Verify the secret against an encoded hash, ok is false if the hash is not from this hasher
+	Verify(secret, encoded string) (match bool, ok bool)
+	// Outdated returns true if the encoded hash was made with weaker parameters than the hasher now uses
+	Outdated(encoded string) bool
+}
+
+// Default hasher used for all new hashes
+var Default Hasher = Bcrypt{Cost: bcrypt.DefaultCost}
+
+// Hash the secret with the default hasher
+func Hash(secret string) (string, error) {
+	return Default.Hash(secret)
+}
+
+// Verify the secret against a stored hash, upgrade is true if the secret matched but the stored hash should be
+// replaced with one from Hash e.g. it is an unsalted legacy hash
+func Verify(secret, stored string) (match bool, upgrade bool) {
+	if match, ok := Default.Verify(secret, stored); ok {
+		return match, match && Default.Outdated(stored)
+	}
+
+	if isLegacy(stored) {
+		match := legacyHash(secret) == strings.ToLower(stored)
+		return match, match
+	}
+
+	return false, false
+}
+
+// legacyHash is the unsalted sha512 hex digest accounts were originally stored with
+func legacyHash(secret string) string {
+	hasher := sha512.New()
+	hasher.Write([]byte(secret))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func isLegacy(stored string) bool {
+	if len(stored) != sha512.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(stored)
+
+	return err == nil
+}
+
+// Bcrypt hashes in the standard $2a$ encoding, which holds the cost and salt
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash the secret with a new random salt
+func (b Bcrypt) Hash(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), b.Cost)
+	return string(hash), err
+}
+
+// Verify the secret using the cost and salt stored in the encoded hash
+func (b Bcrypt) Verify(secret, encoded string) (bool, bool) {
+	if _, err := bcrypt.Cost([]byte(encoded)); err != nil {
+		return false, false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(secret)) == nil, true
+}
+
+// Outdated if the hash used a lower cost than the hasher is configured with
+func (b Bcrypt) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < b.Cost
+}
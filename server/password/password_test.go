@@ -0,0 +1,81 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashRoundTrip(t *testing.T) {
+	hash, err := Hash("secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match, upgrade := Verify("secret", hash); !match || upgrade {
+		t.Errorf("Verify(secret) = %v, %v, want true, false", match, upgrade)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	hash, err := Hash("secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match, upgrade := Verify("wrong", hash); match || upgrade {
+		t.Errorf("Verify(wrong) = %v, %v, want false, false", match, upgrade)
+	}
+
+	if match, _ := Verify("secret", "not a hash"); match {
+		t.Error("Verify matched an invalid stored hash")
+	}
+}
+
+func TestLegacyUpgrade(t *testing.T) {
+	// sha512("secret")
+	legacy := "bd2b1aaf7ef4f09be9f52ce2d8d599674d81aa9d6a4421696dc4d93dd0619d682ce56b4d64a9ef097761ced99e0f67265b5f76085e5b0ee7ca4696b2ad6fe2b2"
+
+	if match, upgrade := Verify("secret", legacy); !match || !upgrade {
+		t.Errorf("Verify(secret, legacy) = %v, %v, want true, true", match, upgrade)
+	}
+
+	if match, upgrade := Verify("secret", strings.ToUpper(legacy)); !match || !upgrade {
+		t.Errorf("Verify(secret, upper case legacy) = %v, %v, want true, true", match, upgrade)
+	}
+
+	if match, upgrade := Verify("wrong", legacy); match || upgrade {
+		t.Errorf("Verify(wrong, legacy) = %v, %v, want false, false", match, upgrade)
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	b := Bcrypt{Cost: bcrypt.MinCost + 1}
+
+	weak, err := Bcrypt{Cost: bcrypt.MinCost}.Hash("secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := b.Hash("secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !b.Outdated(weak) {
+		t.Error("lower cost hash not outdated")
+	}
+
+	if b.Outdated(current) {
+		t.Error("current cost hash outdated")
+	}
+
+	if !b.Outdated("not a hash") {
+		t.Error("invalid hash not outdated")
+	}
+}
@@ -0,0 +1,89 @@
+package player
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+)
+
+// ErrNotDeleted is returned when restoring a character that has not been deleted
+var ErrNotDeleted = errors.New("character is not deleted")
+
+// SoftDelete marks the character as deleted, its name stays taken until it is purged
+func SoftDelete(db *sql.DB, id int32) error {
+	_, err := db.Exec("UPDATE characters SET deletedAt=? WHERE id=? AND deletedAt IS NULL", time.Now().Unix(), id)
+	return err
+}
+
+// Restore a deleted character that has not been purged yet
+func Restore(db *sql.DB, id int32) error {
+	res, err := db.Exec("UPDATE characters SET deletedAt=NULL WHERE id=? AND deletedAt IS NOT NULL", id)
+
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotDeleted
+	}
+
+	return nil
+}
+
+// PurgeDeleted removes characters deleted longer ago than the grace period along with their items and skills
+func PurgeDeleted(db *sql.DB, t time.Time) (int, error) {
+	rows, err := db.Query("SELECT id FROM characters WHERE deletedAt IS NOT NULL AND deletedAt < ?",
+		t.Unix()-constant.CharacterDeleteGracePeriod)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ids := []int32{}
+
+	for rows.Next() {
+		var id int32
+
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	for i, id := range ids {
+		if err := purge(db, id); err != nil {
+			return i, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+func purge(db *sql.DB, id int32) error {
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM items WHERE characterID=?",
+		"DELETE FROM skills WHERE characterID=?",
+		"DELETE FROM characters WHERE id=?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,73 @@
+package player
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/message"
+)
+
+func (d Data) allItems() []item.Data {
+	items := make([]item.Data, 0, len(d.equip)+len(d.use)+len(d.setUp)+len(d.etc)+len(d.cash))
+	items = append(items, d.equip...)
+	items = append(items, d.use...)
+	items = append(items, d.setUp...)
+	items = append(items, d.etc...)
+	items = append(items, d.cash...)
+
+	return items
+}
+
+// RemoveExpiredItems from the player's inventories and tell them which items have expired
+func (d *Data) RemoveExpiredItems(t time.Time, db *sql.DB) {
+	equipChanged := false
+
+	for _, v := range d.allItems() {
+		if !v.Expired(t) {
+			continue
+		}
+
+		if d.pet != nil && v.InvID() == 5 && d.pet.slotID == v.SlotID() {
+			d.DespawnPet(db)
+		}
+
+		if v.InvID() == 1 && v.SlotID() < 0 {
+			equipChanged = true
+		}
+
+		d.removeItem(v, db)
+		d.Send(message.PacketMessageItemExpired(v.ID()))
+	}
+
+	if equipChanged && d.inst != nil {
+		d.inst.Send(packetInventoryChangeEquip(*d))
+	}
+}
+
+// RemoveLogoutItems that only last until the player leaves the channel, nothing is sent as the player is leaving
+func (d *Data) RemoveLogoutItems(db *sql.DB) {
+	keep := func(items []item.Data) []item.Data {
+		kept := items[:0]
+
+		for _, v := range items {
+			if !v.ExpireOnLogout() {
+				kept = append(kept, v)
+				continue
+			}
+
+			if err := v.Delete(db); err != nil {
+				log.Println("Unable to delete logout item", v.ID(), "for", d.name, err)
+			}
+		}
+
+		return kept
+	}
+
+	d.equip = keep(d.equip)
+	d.use = keep(d.use)
+	d.setUp = keep(d.setUp)
+	d.etc = keep(d.etc)
+	d.cash = keep(d.cash)
+}
@@ -0,0 +1,49 @@
+package player
+
+import (
+	"fmt"
+
+	skills "github.com/Hucaru/Valhalla/constant/skill"
+)
+
+// AdvanceJob checks the player can advance into the job from their current one and meets its level and stat
+// requirements, then changes job, gives the sp, max hp and max mp of the advancement and shows the effect to the map.
+// This is what npc scripts should use rather than setting the job directly.
+func (d *Data) AdvanceJob(jobID int16) error {
+	adv, ok := skills.Advancements[jobID]
+
+	if !ok {
+		return fmt.Errorf("Job %d cannot be advanced into", jobID)
+	}
+
+	if d.job != adv.From {
+		return fmt.Errorf("Job %d can only be advanced into from job %d", jobID, adv.From)
+	}
+
+	if d.level < adv.Level {
+		return fmt.Errorf("Level %d is required to advance", adv.Level)
+	}
+
+	if d.str < adv.Str || d.dex < adv.Dex || d.intt < adv.Int || d.luk < adv.Luk {
+		return fmt.Errorf("Stat requirements to advance are not met")
+	}
+
+	d.SetJob(jobID)
+	d.GiveSP(adv.SP)
+
+	if adv.HP > 0 {
+		d.SetMaxHP(d.maxHP + adv.HP)
+	}
+
+	if adv.MP > 0 {
+		d.SetMaxMP(d.maxMP + adv.MP)
+	}
+
+	d.Send(packetPlayerJobChangeEffect())
+
+	if d.inst != nil {
+		d.inst.Send(packetPlayerJobChangeAnimation(d.id))
+	}
+
+	return nil
+}
@@ -17,7 +17,7 @@ func GetCharactersFromAccountWorldID(db *sql.DB, accountID int32, worldID byte)
 		"luk,hp,maxHP,mp,maxMP,ap,sp, exp,fame,mapID,mapPos,previousMapID,mesos," +
 		"equipSlotSize,useSlotSize,setupSlotSize,etcSlotSize,cashSlotSize"
 
-	chars, err := db.Query("SELECT "+filter+" FROM characters WHERE accountID=? AND worldID=?", accountID, worldID)
+	chars, err := db.Query("SELECT "+filter+" FROM characters WHERE accountID=? AND worldID=? AND deletedAt IS NULL", accountID, worldID)
 
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,35 @@
+package player
+
+import (
+	"time"
+)
+
+// Speed stat of the player as a percentage, base plus equips and buffs
+func (d Data) Speed() int16 {
+	return d.TotalStats().Speed
+}
+
+// Jump stat of the player as a percentage, base plus equips and buffs
+func (d Data) Jump() int16 {
+	return d.TotalStats().Jump
+}
+
+// Admin returns true if the player's account can use gm movement e.g. click teleport
+func (d Data) Admin() bool {
+	return d.conn.GetAdminLevel() > 0
+}
+
+// LastMoveTime the server accepted a movement from the player
+func (d Data) LastMoveTime() time.Time {
+	return d.lastMoveTime
+}
+
+// Suspicion score built up from movement that failed validation
+func (d Data) Suspicion() int {
+	return d.suspicion
+}
+
+// AddSuspicion to the player's score
+func (d *Data) AddSuspicion(amount int) {
+	d.suspicion += amount
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/pos"
 )
 
 func packetPlayerReceivedDmg(charID int32, attack int8, initalAmmount, reducedAmmount, spawnID, mobID, healSkillID int32,
@@ -45,6 +46,23 @@ func packetPlayerLevelUpAnimation(charID int32) mpacket.Packet {
 	return p
 }
 
+// packetPlayerJobChangeAnimation shown to the map when a player advances job
+func packetPlayerJobChangeAnimation(charID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPlayerAnimation)
+	p.WriteInt32(charID)
+	p.WriteByte(0x08)
+
+	return p
+}
+
+// packetPlayerJobChangeEffect shown to the player that advanced job
+func packetPlayerJobChangeEffect() mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelLevelUpAnimation)
+	p.WriteByte(0x08)
+
+	return p
+}
+
 func packetPlayerMove(charID int32, bytes []byte) mpacket.Packet {
 	p := mpacket.CreateWithOpcode(opcode.SendChannelPlayerMovement)
 	p.WriteInt32(charID)
@@ -145,141 +163,7 @@ func PacketPlayerEnterGame(plr Data, channelID int32) mpacket.Packet {
 	p.WriteBytes(randomBytes)
 	p.WriteBytes(randomBytes)
 
-	// Are active buffs name encoded in here?
-	p.WriteByte(0xFF)
-	p.WriteByte(0xFF)
-
-	p.WriteInt32(plr.id)
-	p.WritePaddedString(plr.name, 13)
-	p.WriteByte(plr.gender)
-	p.WriteByte(plr.skin)
-	p.WriteInt32(plr.face)
-	p.WriteInt32(plr.hair)
-
-	p.WriteInt64(0) // Pet Cash ID
-
-	p.WriteByte(plr.level)
-	p.WriteInt16(plr.job)
-	p.WriteInt16(plr.str)
-	p.WriteInt16(plr.dex)
-	p.WriteInt16(plr.intt)
-	p.WriteInt16(plr.luk)
-	p.WriteInt16(plr.hp)
-	p.WriteInt16(plr.maxHP)
-	p.WriteInt16(plr.mp)
-	p.WriteInt16(plr.maxMP)
-	p.WriteInt16(plr.ap)
-	p.WriteInt16(plr.sp)
-	p.WriteInt32(plr.exp)
-	p.WriteInt16(plr.fame)
-
-	p.WriteInt32(plr.mapID)
-	p.WriteByte(plr.mapPos)
-
-	p.WriteByte(20) // budy list size
-	p.WriteInt32(plr.mesos)
-
-	p.WriteByte(plr.equipSlotSize)
-	p.WriteByte(plr.useSlotSize)
-	p.WriteByte(plr.setupSlotSize)
-	p.WriteByte(plr.etcSlotSize)
-	p.WriteByte(plr.cashSlotSize)
-
-	for _, v := range plr.equip {
-		if v.SlotID() < 0 && !v.Cash() {
-			p.WriteBytes(v.InventoryBytes())
-		}
-	}
-
-	p.WriteByte(0)
-
-	// Equips
-	for _, v := range plr.equip {
-		if v.SlotID() < 0 && v.Cash() {
-			p.WriteBytes(v.InventoryBytes())
-		}
-	}
-
-	p.WriteByte(0)
-
-	// Inventory windows starts
-	for _, v := range plr.equip {
-		if v.SlotID() > -1 {
-			p.WriteBytes(v.InventoryBytes())
-		}
-	}
-
-	p.WriteByte(0)
-
-	for _, v := range plr.use {
-		p.WriteBytes(v.InventoryBytes())
-	}
-
-	p.WriteByte(0)
-
-	for _, v := range plr.setUp {
-		p.WriteBytes(v.InventoryBytes())
-	}
-
-	p.WriteByte(0)
-
-	for _, v := range plr.etc {
-		p.WriteBytes(v.InventoryBytes())
-	}
-
-	p.WriteByte(0)
-
-	for _, v := range plr.cash {
-		p.WriteBytes(v.InventoryBytes())
-	}
-
-	p.WriteByte(0)
-
-	// Skills
-	p.WriteInt16(int16(len(plr.skills))) // number of skills
-
-	skillCooldowns := make(map[int32]int16)
-
-	for _, skill := range plr.skills {
-		p.WriteInt32(skill.ID)
-		p.WriteInt32(int32(skill.Level))
-
-		if skill.Cooldown > 0 {
-			skillCooldowns[skill.ID] = skill.Cooldown
-		}
-	}
-
-	p.WriteInt16(int16(len(skillCooldowns))) // number of cooldowns
-
-	for id, cooldown := range skillCooldowns {
-		p.WriteInt32(id)
-		p.WriteInt16(cooldown)
-	}
-
-	// Quests
-	p.WriteInt16(3) // Active quest count
-	p.WriteInt16(2029)
-	p.WriteString("")
-	p.WriteInt16(2000)
-	p.WriteString("")
-	p.WriteInt16(1000)
-	p.WriteString("")
-	p.WriteInt16(0) // Completed quest count?
-
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
-	p.WriteInt32(0)
+	p.WriteBytes(plr.InfoBytes())
 	p.WriteInt64(time.Now().Unix())
 
 	return p
@@ -437,3 +321,76 @@ func packetGuildInfo(id int32, name string, memberCount byte) mpacket.Packet {
 
 	return p
 }
+
+func packetPetSpawn(charID int32, petItem item.Data, petPos pos.Data, stance byte) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetSpawn)
+	p.WriteInt32(charID)
+	p.WriteBool(true) // spawn
+	p.WriteInt32(petItem.ID())
+	p.WriteString(petItem.PetName())
+	p.WriteInt64(petItem.DbID())
+	p.WriteInt16(petPos.X())
+	p.WriteInt16(petPos.Y())
+	p.WriteByte(stance)
+	p.WriteInt16(petPos.Foothold())
+
+	return p
+}
+
+func packetPetDespawn(charID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetSpawn)
+	p.WriteInt32(charID)
+	p.WriteBool(false) // spawn
+	p.WriteByte(0)     // 0 - put away, 1 - hungry, 2 - expired
+
+	return p
+}
+
+func packetPetMove(charID int32, bytes []byte) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetMove)
+	p.WriteInt32(charID)
+	p.WriteBytes(bytes)
+
+	return p
+}
+
+func packetPetChat(charID int32, chatType, action byte, msg string) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetAction)
+	p.WriteInt32(charID)
+	p.WriteByte(chatType)
+	p.WriteByte(action)
+	p.WriteString(msg)
+
+	return p
+}
+
+func packetPetNameChange(charID int32, name string) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetNameChange)
+	p.WriteInt32(charID)
+	p.WriteString(name)
+
+	return p
+}
+
+func packetPetCommandResponse(charID int32, food bool, commandID byte, success bool) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPetCommandResponse)
+	p.WriteInt32(charID)
+	p.WriteBool(food)
+
+	if !food {
+		p.WriteByte(commandID)
+	}
+
+	p.WriteBool(success)
+
+	return p
+}
+
+func packetPetLevelUp(charID int32) mpacket.Packet {
+	p := mpacket.CreateWithOpcode(opcode.SendChannelPlayerAnimation)
+	p.WriteInt32(charID)
+	p.WriteByte(0x04) // pet level up
+	p.WriteByte(0x00) // pet slot
+
+	return p
+}
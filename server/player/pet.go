@@ -0,0 +1,348 @@
+package player
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/item"
+	"github.com/Hucaru/Valhalla/server/pos"
+)
+
+// pet that is currently summoned, the persistent stats live on the pet item in the cash inventory
+type pet struct {
+	slotID           int16
+	pos              pos.Data
+	stance           byte
+	lastHungerUpdate time.Time
+}
+
+func (d Data) petItem() (item.Data, error) {
+	if d.pet == nil {
+		return item.Data{}, fmt.Errorf("No pet summoned")
+	}
+
+	return d.GetItem(5, d.pet.slotID)
+}
+
+// refreshPetItem with the changed pet stats in memory and in the client inventory, the stats are saved on despawn or logout
+func (d *Data) refreshPetItem(petItem item.Data) {
+	d.updateItem(petItem)
+	d.Send(packetInventoryAddItem(petItem, true))
+}
+
+// updatePetItem saves the changed pet stats and refreshes the pet item in the client inventory
+func (d *Data) updatePetItem(petItem item.Data, db *sql.DB) {
+	d.refreshPetItem(petItem)
+
+	if _, err := petItem.Save(db, d.id); err != nil {
+		log.Println(err)
+	}
+}
+
+// PetSummoned checks if the player currently has a pet out
+func (d Data) PetSummoned() bool {
+	return d.pet != nil
+}
+
+// PetSpawnPacket used to show the player's pet to others, false if no pet is summoned
+func (d Data) PetSpawnPacket() (mpacket.Packet, bool) {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return nil, false
+	}
+
+	return packetPetSpawn(d.id, petItem, d.pet.pos, d.pet.stance), true
+}
+
+// SpawnPet from the cash inventory slot, using the slot of the summoned pet puts it away
+func (d *Data) SpawnPet(slotID int16, db *sql.DB) error {
+	if d.pet != nil && d.pet.slotID == slotID {
+		d.DespawnPet(db)
+		return nil
+	}
+
+	petItem, err := d.GetItem(5, slotID)
+
+	if err != nil {
+		return err
+	}
+
+	if !petItem.Pet() {
+		return fmt.Errorf("Item %d is not a pet", petItem.ID())
+	}
+
+	if petItem.PetFullness() == 0 {
+		return fmt.Errorf("Pet %d is too hungry to be summoned", petItem.ID())
+	}
+
+	d.DespawnPet(db)
+
+	d.pet = &pet{slotID: slotID, pos: d.pos, stance: d.stance, lastHungerUpdate: time.Now()}
+	d.inst.Send(packetPetSpawn(d.id, petItem, d.pet.pos, d.pet.stance))
+
+	return nil
+}
+
+// DespawnPet if one is summoned, saving the stats it gained or lost while out
+func (d *Data) DespawnPet(db *sql.DB) {
+	if d.pet == nil {
+		return
+	}
+
+	if petItem, err := d.petItem(); err == nil {
+		if _, err := petItem.Save(db, d.id); err != nil {
+			log.Println(err)
+		}
+	}
+
+	d.pet = nil
+
+	if d.inst != nil {
+		d.inst.Send(packetPetDespawn(d.id))
+	}
+}
+
+// MovePet relays the pet movement to the rest of the field
+func (d *Data) MovePet(moveBytes []byte, frag movementFrag) {
+	if d.pet == nil {
+		return
+	}
+
+	d.pet.pos.SetX(frag.X())
+	d.pet.pos.SetY(frag.Y())
+	d.pet.pos.SetFoothold(frag.Foothold())
+	d.pet.stance = frag.Stance()
+
+	d.inst.SendExcept(packetPetMove(d.id, moveBytes), d.conn)
+}
+
+// PetChat relays what the pet says to the field
+func (d Data) PetChat(chatType, action byte, msg string) {
+	if d.pet == nil {
+		return
+	}
+
+	d.inst.Send(packetPetChat(d.id, chatType, action, msg))
+}
+
+// PetCommand attempts the interaction with the pet, success raises the pet's closeness
+func (d *Data) PetCommand(commandID byte, db *sql.DB) error {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return err
+	}
+
+	nxInfo, err := nx.GetItem(petItem.ID())
+
+	if err != nil {
+		return err
+	}
+
+	command, ok := nxInfo.PetCommands[commandID]
+
+	if !ok {
+		return fmt.Errorf("Pet %d does not have command %d", petItem.ID(), commandID)
+	}
+
+	level := int64(petItem.PetLevel())
+
+	if level < command.L0 || (command.L1 > 0 && level > command.L1) {
+		return fmt.Errorf("Pet %d is not the right level for command %d", petItem.ID(), commandID)
+	}
+
+	success := rand.Int63n(100) < command.Prob
+
+	if success {
+		d.givePetCloseness(&petItem, int16(command.Inc))
+		d.updatePetItem(petItem, db)
+	}
+
+	d.inst.Send(packetPetCommandResponse(d.id, false, commandID, success))
+
+	return nil
+}
+
+// FeedPet a piece of pet food from the use inventory, feeding a full pet lowers its closeness
+func (d *Data) FeedPet(slotID int16, itemID int32, db *sql.DB) error {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return err
+	}
+
+	if itemID/1e4 != constant.PetFoodItemType {
+		return fmt.Errorf("Item %d is not pet food", itemID)
+	}
+
+	if _, err := d.TakeItem(itemID, slotID, 1, 2, db); err != nil {
+		return err
+	}
+
+	hungry := petItem.PetFullness() < constant.PetMaxFullness
+
+	if hungry {
+		fullness := int(petItem.PetFullness()) + constant.PetFoodFullness
+
+		if fullness > constant.PetMaxFullness {
+			fullness = constant.PetMaxFullness
+		}
+
+		petItem.SetPetFullness(byte(fullness))
+		d.givePetCloseness(&petItem, 1)
+	} else {
+		d.givePetCloseness(&petItem, -1)
+	}
+
+	d.updatePetItem(petItem, db)
+	d.inst.Send(packetPetCommandResponse(d.id, true, 0, hungry))
+
+	return nil
+}
+
+// NamePet using a name tag from the cash inventory
+func (d *Data) NamePet(tagSlotID int16, name string, db *sql.DB) error {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return err
+	}
+
+	if len(name) < 1 || len(name) > 12 {
+		return fmt.Errorf("Invalid pet name length %d", len(name))
+	}
+
+	if _, err := d.TakeItem(constant.PetNameTagItemID, tagSlotID, 1, 5, db); err != nil {
+		return err
+	}
+
+	petItem.SetPetName(name)
+	d.updatePetItem(petItem, db)
+	d.inst.Send(packetPetNameChange(d.id, name))
+
+	return nil
+}
+
+// givePetCloseness and handle any resulting change in level
+func (d *Data) givePetCloseness(petItem *item.Data, amount int16) {
+	closeness := petItem.PetCloseness() + amount
+
+	if closeness < 0 {
+		closeness = 0
+	} else if closeness > constant.PetMaxCloseness {
+		closeness = constant.PetMaxCloseness
+	}
+
+	petItem.SetPetCloseness(closeness)
+
+	level := byte(1)
+
+	for level < constant.PetMaxLevel && closeness >= constant.PetClosenessTable[level-1] {
+		level++
+	}
+
+	if level > petItem.PetLevel() {
+		d.inst.Send(packetPetLevelUp(d.id))
+	}
+
+	petItem.SetPetLevel(level)
+}
+
+// UpdatePet hunger, a pet that runs out of fullness loses closeness and returns home
+func (d *Data) UpdatePet(t time.Time, db *sql.DB) {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return
+	}
+
+	nxInfo, err := nx.GetItem(petItem.ID())
+
+	if err != nil {
+		return
+	}
+
+	hungry := nxInfo.Hungry
+
+	if hungry < 1 {
+		hungry = 1
+	}
+
+	interval := time.Duration(constant.PetHungerInterval/hungry) * time.Second
+
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	if t.Sub(d.pet.lastHungerUpdate) < interval {
+		return
+	}
+
+	d.pet.lastHungerUpdate = t
+
+	if petItem.PetFullness() > 0 {
+		petItem.SetPetFullness(petItem.PetFullness() - 1)
+	}
+
+	if petItem.PetFullness() == 0 {
+		d.givePetCloseness(&petItem, -1)
+	}
+
+	d.refreshPetItem(petItem)
+
+	if petItem.PetFullness() == 0 {
+		d.DespawnPet(db)
+	}
+}
+
+// PetCanLoot checks the pet is close enough and has the pickup ability for the drop at the given position
+func (d Data) PetCanLoot(dropPos pos.Data) bool {
+	petItem, err := d.petItem()
+
+	if err != nil {
+		return false
+	}
+
+	var pickupItem, sweep bool
+
+	addAbilities := func(itemID int32) {
+		nxInfo, err := nx.GetItem(itemID)
+
+		if err != nil {
+			return
+		}
+
+		pickupItem = pickupItem || nxInfo.PickupItem > 0 || nxInfo.PickupAll > 0
+		sweep = sweep || nxInfo.SweepForDrop > 0
+	}
+
+	addAbilities(petItem.ID())
+
+	for _, v := range d.equip {
+		if v.SlotID() < 0 && v.ID()/1e4 == constant.PetEquipItemType {
+			addAbilities(v.ID())
+		}
+	}
+
+	if !pickupItem {
+		return false
+	}
+
+	var pickupRange int16 = constant.PetPickupRange
+
+	if sweep {
+		pickupRange = constant.PetSweepPickupRange
+	}
+
+	dx := d.pet.pos.X() - dropPos.X()
+	dy := d.pet.pos.Y() - dropPos.Y()
+
+	return dx > -pickupRange && dx < pickupRange && dy > -pickupRange && dy < pickupRange
+}
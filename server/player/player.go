@@ -6,10 +6,12 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"time"
 
 	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/field/droppool"
 	"github.com/Hucaru/Valhalla/server/item"
 	"github.com/Hucaru/Valhalla/server/pos"
 )
@@ -20,8 +22,10 @@ type sender interface {
 
 type instance interface {
 	sender
+	SendExcept(mpacket.Packet, mnet.Client) error
 	CalculateNearestSpawnPortalID(pos.Data) (byte, error)
 	ID() int
+	DropPool() *droppool.Data
 }
 
 // Data connected to server
@@ -84,6 +88,11 @@ type Data struct {
 	miniGameWins, miniGameDraw, miniGameLoss, miniGamePoints int32
 
 	lastAttackPacketTime int64
+
+	lastMoveTime time.Time
+	suspicion    int
+
+	pet *pet
 }
 
 // Conn - client connection associated with this Data
@@ -99,6 +108,10 @@ func (d Data) InstanceID() int {
 // SetInstance of player
 func (d *Data) SetInstance(inst interface{}) {
 	d.inst, _ = inst.(instance)
+
+	if d.pet != nil {
+		d.pet.pos = d.pos
+	}
 }
 
 // Send the Data a packet
@@ -309,7 +322,8 @@ func (d *Data) SetMaxMP(amount int16) {
 
 // SetFame of Data
 func (d *Data) SetFame(amount int16) {
-
+	d.fame = amount
+	d.Send(packetPlayerStatChange(false, constant.FameID, int32(amount)))
 }
 
 // IncrementPortalCount of player
@@ -406,6 +420,7 @@ func (d *Data) UpdateMovement(frag movementFrag) {
 	d.pos.SetY(frag.Y())
 	d.pos.SetFoothold(frag.Foothold())
 	d.stance = frag.Stance()
+	d.lastMoveTime = time.Now()
 }
 
 // SetPos of Data
@@ -437,6 +452,11 @@ func (d *Data) SetMapID(id int32) {
 	d.mapID = id
 }
 
+// SetChairID of the portable chair the Data is sitting on, 0 when not sitting on one
+func (d *Data) SetChairID(id int32) {
+	d.chairID = id
+}
+
 // SetMapPosID of Data
 func (d *Data) SetMapPosID(pos byte) {
 	d.mapPos = pos
@@ -500,6 +520,7 @@ func (d *Data) GiveItem(newItem item.Data, db *sql.DB) error { // TODO: Refactor
 			size -= constant.MaxItemStack
 
 			newItem.SetAmount(value)
+			newItem.SetDbID(0) // each stack is its own row
 
 			var slotID int16
 			var index int
@@ -571,6 +592,7 @@ func (d *Data) GiveItem(newItem item.Data, db *sql.DB) error { // TODO: Refactor
 			size -= constant.MaxItemStack
 
 			newItem.SetAmount(value)
+			newItem.SetDbID(0) // each stack is its own row
 
 			var slotID int16
 			var index int
@@ -636,11 +658,33 @@ func (d *Data) GiveItem(newItem item.Data, db *sql.DB) error { // TODO: Refactor
 	return nil
 }
 
-// TakeItem from Data
-func (d *Data) TakeItem(itemID int32, amount int16) (item.Data, error) {
-	log.Println("player.Data.TakeItem not implemented")
-	// removeItem if item.Amount() == 0
-	return item.Data{}, nil
+// TakeItem amount from the item in the given slot, the item is removed once none remain
+func (d *Data) TakeItem(itemID int32, slotID int16, amount int16, invID byte, db *sql.DB) (item.Data, error) {
+	taken, err := d.GetItem(invID, slotID)
+
+	if err != nil {
+		return taken, err
+	}
+
+	if taken.ID() != itemID {
+		return taken, fmt.Errorf("Item in slot %d is %d not %d", slotID, taken.ID(), itemID)
+	}
+
+	if amount < 1 || taken.Amount() < amount {
+		return taken, fmt.Errorf("Cannot take %d of item %d", amount, itemID)
+	}
+
+	taken.SetAmount(taken.Amount() - amount)
+
+	if taken.Amount() == 0 {
+		d.removeItem(taken, db)
+	} else {
+		taken.Save(db, d.id)
+		d.updateItem(taken)
+		d.Send(packetInventoryAddItem(taken, false))
+	}
+
+	return taken, nil
 }
 
 func (d *Data) updateItem(new item.Data) {
@@ -667,7 +711,8 @@ func (d *Data) updateItem(new item.Data) {
 	}
 }
 
-func (d Data) getItem(invID byte, slotID int16) (item.Data, error) {
+// GetItem from the given inventory slot
+func (d Data) GetItem(invID byte, slotID int16) (item.Data, error) {
 	var items []item.Data
 
 	switch invID {
@@ -692,6 +737,32 @@ func (d Data) getItem(invID byte, slotID int16) (item.Data, error) {
 	return item.Data{}, fmt.Errorf("Could not find item")
 }
 
+// Equipped returns true if the item is worn
+func (d Data) Equipped(itemID int32) bool {
+	for _, v := range d.equip {
+		if v.ID() == itemID && v.SlotID() < 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ItemCount of the given item across all inventories
+func (d Data) ItemCount(itemID int32) int {
+	count := 0
+
+	for _, items := range [][]item.Data{d.equip, d.use, d.setUp, d.etc, d.cash} {
+		for _, v := range items {
+			if v.ID() == itemID {
+				count += int(v.Amount())
+			}
+		}
+	}
+
+	return count
+}
+
 func (d *Data) swapItems(item1, item2 item.Data, start, end int16, db *sql.DB) {
 	item1.SetSlotID(end)
 	item1.Save(db, d.id)
@@ -752,38 +823,64 @@ func (d *Data) removeItem(item item.Data, db *sql.DB) {
 	d.Send(packetInventoryRemoveItem(item))
 }
 
+// RemoveItem from the given inventory slot, returning the removed item
+func (d *Data) RemoveItem(invID byte, slotID int16, db *sql.DB) (item.Data, error) {
+	item, err := d.GetItem(invID, slotID)
+
+	if err != nil {
+		return item, err
+	}
+
+	d.removeItem(item, db)
+
+	return item, nil
+}
+
 // MoveItem from one slot to another, if the final slot is zero then this is a drop action
 func (d *Data) MoveItem(start, end, amount int16, invID byte, inst instance, db *sql.DB) error {
 	if end == 0 { //drop item
-		fmt.Println("Drop item amount:", amount)
-		item, err := d.getItem(invID, start)
+		dropped, err := d.GetItem(invID, start)
 
 		if err != nil {
 			return fmt.Errorf("Item to move doesn't exist")
 		}
 
-		d.removeItem(item, db)
-		// inst.AddDrop()
+		if d.pet != nil && invID == 5 && d.pet.slotID == start {
+			d.DespawnPet(db)
+		}
+
+		if _, err := d.TakeItem(dropped.ID(), start, amount, invID, db); err != nil {
+			return err
+		}
+
+		dropped.SetDbID(0) // the dropped item no longer exists in the database
+		dropped.SetAmount(amount)
+		inst.DropPool().CreatePlayerDrop(d.id, dropped, d.pos)
 	} else if end < 0 { // Move to equip slot
-		item1, err := d.getItem(invID, start)
+		item1, err := d.GetItem(invID, start)
 
 		if err != nil {
 			return fmt.Errorf("Item to move doesn't exist")
 		}
 
+		if err := d.CanEquip(item1, end); err != nil {
+			d.Send(packetInventoryNoChange())
+			return err
+		}
+
 		if item1.TwoHanded() {
-			if _, err := d.getItem(invID, -10); err == nil {
+			if _, err := d.GetItem(invID, -10); err == nil {
 				d.Send(packetInventoryNoChange()) // Should this do switching if space is available?
 				return nil
 			}
 		} else if item1.Shield() {
-			if weapon, err := d.getItem(invID, -11); err == nil && weapon.TwoHanded() {
+			if weapon, err := d.GetItem(invID, -11); err == nil && weapon.TwoHanded() {
 				d.Send(packetInventoryNoChange()) // should this move weapon into into item 1 slot?
 				return nil
 			}
 		}
 
-		item2, err := d.getItem(invID, end)
+		item2, err := d.GetItem(invID, end)
 
 		if err == nil {
 			item2.SetSlotID(start)
@@ -798,13 +895,13 @@ func (d *Data) MoveItem(start, end, amount int16, invID byte, inst instance, db
 		d.Send(packetInventoryChangeItemSlot(invID, start, end))
 		inst.Send(packetInventoryChangeEquip(*d))
 	} else { // move within inventory
-		item1, err := d.getItem(invID, start)
+		item1, err := d.GetItem(invID, start)
 
 		if err != nil {
 			return fmt.Errorf("Item to move doesn't exist")
 		}
 
-		item2, err := d.getItem(invID, end)
+		item2, err := d.GetItem(invID, end)
 
 		if err != nil { // empty slot
 			item1.SetSlotID(end)
@@ -833,6 +930,15 @@ func (d *Data) MoveItem(start, end, amount int16, invID byte, inst instance, db
 			}
 		}
 
+		// pets are not stackable so moving onto another item is always a swap
+		if d.pet != nil && invID == 5 {
+			if d.pet.slotID == start {
+				d.pet.slotID = end
+			} else if d.pet.slotID == end {
+				d.pet.slotID = start
+			}
+		}
+
 		if start < 0 || end < 0 {
 			inst.Send(packetInventoryChangeEquip(*d))
 		}
@@ -1011,6 +1117,149 @@ func (d Data) DisplayBytes() []byte {
 	return pkt
 }
 
+// InfoBytes is the full character information block used when entering the game or the cash shop
+func (d Data) InfoBytes() []byte {
+	pkt := mpacket.NewPacket()
+
+	// Are active buffs name encoded in here?
+	pkt.WriteByte(0xFF)
+	pkt.WriteByte(0xFF)
+
+	pkt.WriteInt32(d.id)
+	pkt.WritePaddedString(d.name, 13)
+	pkt.WriteByte(d.gender)
+	pkt.WriteByte(d.skin)
+	pkt.WriteInt32(d.face)
+	pkt.WriteInt32(d.hair)
+
+	pkt.WriteInt64(0) // Pet Cash ID
+
+	pkt.WriteByte(d.level)
+	pkt.WriteInt16(d.job)
+	pkt.WriteInt16(d.str)
+	pkt.WriteInt16(d.dex)
+	pkt.WriteInt16(d.intt)
+	pkt.WriteInt16(d.luk)
+	pkt.WriteInt16(d.hp)
+	pkt.WriteInt16(d.maxHP)
+	pkt.WriteInt16(d.mp)
+	pkt.WriteInt16(d.maxMP)
+	pkt.WriteInt16(d.ap)
+	pkt.WriteInt16(d.sp)
+	pkt.WriteInt32(d.exp)
+	pkt.WriteInt16(d.fame)
+
+	pkt.WriteInt32(d.mapID)
+	pkt.WriteByte(d.mapPos)
+
+	pkt.WriteByte(20) // budy list size
+	pkt.WriteInt32(d.mesos)
+
+	pkt.WriteByte(d.equipSlotSize)
+	pkt.WriteByte(d.useSlotSize)
+	pkt.WriteByte(d.setupSlotSize)
+	pkt.WriteByte(d.etcSlotSize)
+	pkt.WriteByte(d.cashSlotSize)
+
+	for _, v := range d.equip {
+		if v.SlotID() < 0 && !v.Cash() {
+			pkt.WriteBytes(v.InventoryBytes())
+		}
+	}
+
+	pkt.WriteByte(0)
+
+	// Equips
+	for _, v := range d.equip {
+		if v.SlotID() < 0 && v.Cash() {
+			pkt.WriteBytes(v.InventoryBytes())
+		}
+	}
+
+	pkt.WriteByte(0)
+
+	// Inventory windows starts
+	for _, v := range d.equip {
+		if v.SlotID() > -1 {
+			pkt.WriteBytes(v.InventoryBytes())
+		}
+	}
+
+	pkt.WriteByte(0)
+
+	for _, v := range d.use {
+		pkt.WriteBytes(v.InventoryBytes())
+	}
+
+	pkt.WriteByte(0)
+
+	for _, v := range d.setUp {
+		pkt.WriteBytes(v.InventoryBytes())
+	}
+
+	pkt.WriteByte(0)
+
+	for _, v := range d.etc {
+		pkt.WriteBytes(v.InventoryBytes())
+	}
+
+	pkt.WriteByte(0)
+
+	for _, v := range d.cash {
+		pkt.WriteBytes(v.InventoryBytes())
+	}
+
+	pkt.WriteByte(0)
+
+	// Skills
+	pkt.WriteInt16(int16(len(d.skills))) // number of skills
+
+	skillCooldowns := make(map[int32]int16)
+
+	for _, skill := range d.skills {
+		pkt.WriteInt32(skill.ID)
+		pkt.WriteInt32(int32(skill.Level))
+
+		if skill.Cooldown > 0 {
+			skillCooldowns[skill.ID] = skill.Cooldown
+		}
+	}
+
+	pkt.WriteInt16(int16(len(skillCooldowns))) // number of cooldowns
+
+	for id, cooldown := range skillCooldowns {
+		pkt.WriteInt32(id)
+		pkt.WriteInt16(cooldown)
+	}
+
+	// Quests
+	pkt.WriteInt16(3) // Active quest count
+	pkt.WriteInt16(2029)
+	pkt.WriteString("")
+	pkt.WriteInt16(2000)
+	pkt.WriteString("")
+	pkt.WriteInt16(1000)
+	pkt.WriteString("")
+	pkt.WriteInt16(0) // Completed quest count?
+
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+	pkt.WriteInt32(0)
+
+	return pkt
+}
+
 // Save data
 func (d Data) Save(db *sql.DB) error {
 	query := `UPDATE characters set skin=?, hair=?, face=?, level=?,
@@ -1018,7 +1267,7 @@ func (d Data) Save(db *sql.DB) error {
 	ap=?, sp=?, exp=?, fame=?, mapID=?, mapPos=?, mesos=?, miniGameWins=?,
 	miniGameDraw=?, miniGameLoss=?, miniGamePoints=? WHERE id=?`
 
-	var mapPos byte
+	mapPos := d.mapPos
 	var err error
 
 	if d.inst != nil {
@@ -1051,7 +1300,13 @@ func (d Data) Save(db *sql.DB) error {
 		}
 	}
 
-	return err
+	if petItem, err := d.petItem(); err == nil {
+		if _, err := petItem.Save(db, d.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // UpdateGuildInfo for the player
@@ -0,0 +1,146 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/nx"
+	"github.com/Hucaru/Valhalla/server/item"
+)
+
+// Stats of the player once equips are taken into account, there is no buff system yet to add to them
+type Stats struct {
+	Str, Dex, Int, Luk int16
+	MaxHP, MaxMP       int16
+	Watk, Matk         int16
+	Wdef, Mdef         int16
+	Accuracy, Avoid    int16
+	Hands              int16
+	Speed, Jump        int16
+}
+
+func (s *Stats) add(other Stats) {
+	s.Str += other.Str
+	s.Dex += other.Dex
+	s.Int += other.Int
+	s.Luk += other.Luk
+	s.MaxHP += other.MaxHP
+	s.MaxMP += other.MaxMP
+	s.Watk += other.Watk
+	s.Matk += other.Matk
+	s.Wdef += other.Wdef
+	s.Mdef += other.Mdef
+	s.Accuracy += other.Accuracy
+	s.Avoid += other.Avoid
+	s.Hands += other.Hands
+	s.Speed += other.Speed
+	s.Jump += other.Jump
+}
+
+func equipStats(v item.Data) Stats {
+	return Stats{
+		Str: v.Str(), Dex: v.Dex(), Int: v.Int(), Luk: v.Luk(),
+		MaxHP: v.Hp(), MaxMP: v.Mp(),
+		Watk: v.Watk(), Matk: v.Matk(),
+		Wdef: v.Wdef(), Mdef: v.Mdef(),
+		Accuracy: v.Accuracy(), Avoid: v.Avoid(),
+		Hands: v.Hands(),
+		Speed: v.Speed(), Jump: v.Jump(),
+	}
+}
+
+// BaseStats of the player without equips
+func (d Data) BaseStats() Stats {
+	return Stats{Str: d.str, Dex: d.dex, Int: d.intt, Luk: d.luk, MaxHP: d.maxHP, MaxMP: d.maxMP, Speed: 100, Jump: 100}
+}
+
+// TotalStats is the base stats plus all worn equips, speed and jump are capped
+func (d Data) TotalStats() Stats {
+	stats := d.BaseStats()
+
+	for _, v := range d.equip {
+		if v.SlotID() < 0 {
+			stats.add(equipStats(v))
+		}
+	}
+
+	if stats.Speed > constant.MovementMaxSpeedStat {
+		stats.Speed = constant.MovementMaxSpeedStat
+	}
+
+	if stats.Jump > constant.MovementMaxJumpStat {
+		stats.Jump = constant.MovementMaxJumpStat
+	}
+
+	return stats
+}
+
+// Weapon the player has equipped
+func (d Data) Weapon() (item.Data, bool) {
+	weapon, err := d.GetItem(1, -11)
+
+	return weapon, err == nil
+}
+
+// jobAllowed checks the job against an equip's job requirement, a bit for each job branch with -1 for beginners only
+func jobAllowed(reqJob int64, job int16) bool {
+	if reqJob == 0 {
+		return true
+	}
+
+	branch := job / 100
+
+	if reqJob < 0 {
+		return branch == 0
+	}
+
+	if branch == 0 {
+		return false
+	}
+
+	if branch >= constant.GmJobID/100 {
+		return true
+	}
+
+	return reqJob&(1<<uint(branch-1)) != 0
+}
+
+// CanEquip checks the player meets the level, stat, job and fame requirements of the item and that it can be worn in
+// the slot. Requirements are checked against total stats without the item that would be replaced.
+func (d Data) CanEquip(v item.Data, slot int16) error {
+	if !v.EquipSlotValid(slot) {
+		return fmt.Errorf("Item %d cannot be worn in slot %d", v.ID(), slot)
+	}
+
+	if d.Admin() {
+		return nil
+	}
+
+	info, err := nx.GetItem(v.ID())
+
+	if err != nil {
+		return err
+	}
+
+	stats := d.TotalStats()
+
+	if replaced, err := d.GetItem(1, slot); err == nil {
+		stats.Str -= replaced.Str()
+		stats.Dex -= replaced.Dex()
+		stats.Int -= replaced.Int()
+		stats.Luk -= replaced.Luk()
+	}
+
+	switch {
+	case d.level < info.ReqLevel:
+		return fmt.Errorf("Level %d is required", info.ReqLevel)
+	case stats.Str < info.ReqSTR, stats.Dex < info.ReqDEX, stats.Int < info.ReqINT, stats.Luk < info.ReqLUK:
+		return fmt.Errorf("Stat requirements are not met")
+	case !jobAllowed(info.ReqJob, d.job):
+		return fmt.Errorf("Job requirement is not met")
+	case int64(d.fame) < info.ReqPOP:
+		return fmt.Errorf("%d fame is required", info.ReqPOP)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package event
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/Hucaru/Valhalla/server/script"
+)
+
+// Definition of an event such as a party quest, loaded from an event script
+type Definition struct {
+	Name       string
+	NpcID      int32 `toml:"npc"` // npc that starts the event
+	MinPlayers int
+	MaxPlayers int
+	MinLevel   byte
+	MaxLevel   byte
+	TimeLimit  int32 // seconds the whole event can take
+	ExitMap    int32
+	Stages     []Stage `toml:"stage"`
+}
+
+// Stage of an event played out in a private instance of the map
+type Stage struct {
+	MapID     int32 `toml:"map"`
+	TimeLimit int32 // seconds, zero uses the remaining event time
+	Clear     Condition
+	Reward    Reward
+}
+
+// Condition to clear a stage, a stage without any conditions is a bonus stage that ends on its time limit
+type Condition struct {
+	NpcID     int32 `toml:"npc"` // npc the event leader talks to once everything else is done
+	MobID     int32 `toml:"mob"` // mob that must be killed, zero for any
+	MobCount  int   // number of kills needed
+	ItemID    int32 `toml:"item"` // item that must be dropped on the field
+	ItemCount int   // amount of the item needed
+}
+
+// Empty condition means there is nothing to clear
+func (c Condition) Empty() bool {
+	return c.NpcID == 0 && c.MobCount == 0 && c.ItemCount == 0
+}
+
+// Reward given to every player in the event when a stage is cleared
+type Reward struct {
+	Exp   int32
+	Mesos int32
+	Items []RewardItem
+}
+
+// RewardItem given on stage clear
+type RewardItem struct {
+	ID     int32
+	Amount int16
+}
+
+// Load an event definition from the event script with the given name
+func Load(name string) (Definition, error) {
+	var def Definition
+
+	contents, err := script.Get(name + ".event")
+
+	if err != nil {
+		return def, err
+	}
+
+	_, err = toml.Decode(contents, &def)
+
+	return def, err
+}
+
+// LoadAll event definitions that are currently loaded
+func LoadAll() map[string]Definition {
+	defs := make(map[string]Definition)
+
+	for _, name := range script.GetNames(".event") {
+		def, err := Load(name)
+
+		if err != nil {
+			continue
+		}
+
+		defs[name] = def
+	}
+
+	return defs
+}
@@ -0,0 +1,55 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/Hucaru/Valhalla/constant"
+	"github.com/Hucaru/Valhalla/server/script"
+	"github.com/dop251/goja"
+)
+
+// Script run when a player enters a portal with a script, loaded from scripts/portal. Scripts are javascript and are
+// given the objects in Context, method names start lower case in the script e.g. player.level()
+type Script struct {
+	program *goja.Program
+}
+
+// Context a script runs with, each object is available to the script by the lower case field name
+type Context struct {
+	Player   interface{}
+	Field    interface{}
+	Instance interface{}
+	Portal   interface{}
+}
+
+// Load the portal script with the given name
+func Load(name string) (Script, error) {
+	contents, err := script.Get(name + ".portal")
+
+	if err != nil {
+		return Script{}, err
+	}
+
+	program, err := goja.Compile(name, contents, false)
+
+	return Script{program: program}, err
+}
+
+// Run the script, it is stopped if it takes longer than constant.PortalScriptTimeout
+func (s Script) Run(ctx Context) error {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
+	vm.Set("player", ctx.Player)
+	vm.Set("field", ctx.Field)
+	vm.Set("instance", ctx.Instance)
+	vm.Set("portal", ctx.Portal)
+
+	timer := time.AfterFunc(time.Millisecond*constant.PortalScriptTimeout, func() {
+		vm.Interrupt("portal script timed out")
+	})
+	defer timer.Stop()
+
+	_, err := vm.RunProgram(s.program)
+
+	return err
+}
@@ -5,7 +5,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -27,7 +26,7 @@ func collector() {
 		script := <-fileChan
 
 		if script.remove {
-			loadedScripts.remove(strings.TrimSuffix(script.name, filepath.Ext(script.name)))
+			loadedScripts.remove(script.name)
 		} else {
 			loadedScripts.add(script)
 		}
@@ -50,6 +49,10 @@ func loadScripts(directory string) {
 	scripts := []scriptFile{}
 
 	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
 		if !info.IsDir() {
 			scripts = append(scripts, readScript(path))
 		}
@@ -17,22 +17,21 @@ var loadedScripts = scriptStore{
 	mutex:   &sync.RWMutex{},
 }
 
+// Get the contents of a script by its file name with extension e.g. "market00.portal", scripts of different kinds can
+// share a name so the extension is part of the key
 func Get(name string) (string, error) {
 	return loadedScripts.get(name)
 }
 
 func (ss *scriptStore) add(s scriptFile) {
-	name := filepath.Base(s.name)
-	name = strings.TrimSuffix(name, filepath.Ext(name))
-
 	ss.mutex.Lock()
-	ss.scripts[name] = s
+	ss.scripts[filepath.Base(s.name)] = s
 	ss.mutex.Unlock()
 }
 
 func (ss *scriptStore) remove(name string) {
 	ss.mutex.Lock()
-	delete(ss.scripts, name)
+	delete(ss.scripts, filepath.Base(name))
 	ss.mutex.Unlock()
 }
 
@@ -50,3 +49,22 @@ func (ss *scriptStore) get(name string) (string, error) {
 
 	return s, err
 }
+
+// GetNames of all loaded scripts with the given file extension e.g. ".event", the names do not include the extension
+func GetNames(extension string) []string {
+	return loadedScripts.getNames(extension)
+}
+
+func (ss *scriptStore) getNames(extension string) []string {
+	names := []string{}
+
+	ss.mutex.RLock()
+	for name := range ss.scripts {
+		if filepath.Ext(name) == extension {
+			names = append(names, strings.TrimSuffix(name, extension))
+		}
+	}
+	ss.mutex.RUnlock()
+
+	return names
+}
@@ -4,19 +4,64 @@ import (
 	"log"
 	"time"
 
+	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/constant/opcode"
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
+	"github.com/Hucaru/Valhalla/server/migration"
 )
 
 // WorldServer data
 type WorldServer struct {
-	info  world
-	login mnet.Server
+	id              byte
+	info            world
+	login           mnet.Server
+	cashShop        channel
+	rates           rates
+	configuredRates rates     // rates from the config, restored by a reset
+	baseRates       rates     // rates to return to once a temporary rate change ends, the configured or a permanent change
+	ratesExpire     time.Time // zero when the current rates do not expire
+	maxChannels     int
 }
 
-// RegisterWithLogin server
-func (server *WorldServer) RegisterWithLogin(conn mnet.Server, message string, ribbon byte) {
+// SetRates the world's channels multiply exp, drops and mesos by, rates that are not positive are treated as 1
+func (server *WorldServer) SetRates(exp, drop, mesos float32) {
+	server.rates = rates{exp: exp, drop: drop, mesos: mesos}
+	server.rates.sanitise()
+	server.configuredRates = server.rates
+	server.baseRates = server.rates
+}
+
+// Update ends temporary rate changes that have expired
+func (server *WorldServer) Update(t time.Time) {
+	if server.ratesExpire.IsZero() || t.Before(server.ratesExpire) {
+		return
+	}
+
+	server.rates = server.baseRates
+	server.ratesExpire = time.Time{}
+
+	log.Println("Temporary rates ended")
+	server.sendRates()
+}
+
+// SetMaxChannels that can register with the world, up to constant.MaxChannels
+func (server *WorldServer) SetMaxChannels(max int) {
+	if max < 1 || max > constant.MaxChannels {
+		max = constant.MaxChannels
+	}
+
+	server.maxChannels = max
+}
+
+// RegisterWithLogin server as the world with the given id, an empty name uses the default name for the id
+func (server *WorldServer) RegisterWithLogin(conn mnet.Server, id byte, name, message string, ribbon byte) {
+	if name == "" && int(id) < len(constant.WORLD_NAMES) {
+		name = constant.WORLD_NAMES[id]
+	}
+
+	server.id = id
+	server.info.name = name
 	server.info.message = message
 	server.info.ribbon = ribbon
 
@@ -26,6 +71,7 @@ func (server *WorldServer) RegisterWithLogin(conn mnet.Server, message string, r
 
 func (server *WorldServer) registerWithLogin() {
 	p := mpacket.CreateInternal(opcode.WorldNew)
+	p.WriteByte(server.id)
 	p.WriteString(server.info.name)
 	server.login.Send(p)
 }
@@ -39,6 +85,22 @@ func (server *WorldServer) HandleServerPacket(conn mnet.Server, reader mpacket.R
 		server.handleRequestBad(conn, reader)
 	case opcode.ChannelNew:
 		server.handleNewChannel(conn, reader)
+	case opcode.CashShopNew:
+		server.handleNewCashShop(conn, reader)
+	case opcode.DisconnectAccount:
+		server.handleDisconnectAccount(conn, reader)
+	case opcode.MigrationTicket:
+		server.handleMigrationTicket(conn, reader)
+	case opcode.WorldMessage:
+		server.info.message = reader.ReadString(reader.ReadInt16())
+		server.login.Send(server.info.generateInfoPacket())
+		log.Println("World message changed to", server.info.message)
+	case opcode.WorldRibbon:
+		server.info.ribbon = reader.ReadByte()
+		server.login.Send(server.info.generateInfoPacket())
+		log.Println("World ribbon changed to", server.info.ribbon)
+	case opcode.ChangeRates:
+		server.handleChangeRates(conn, reader)
 	default:
 		log.Println("UNKNOWN SERVER PACKET:", reader)
 	}
@@ -46,6 +108,13 @@ func (server *WorldServer) HandleServerPacket(conn mnet.Server, reader mpacket.R
 
 // ServerDisconnected handler
 func (server *WorldServer) ServerDisconnected(conn mnet.Server) {
+	if server.cashShop.conn == conn {
+		server.cashShop = channel{}
+		log.Println("Lost cash shop")
+		server.sendCashShopInfo()
+		return
+	}
+
 	for i, v := range server.info.channels {
 		if v.conn == conn {
 			server.info.channels[i].conn = nil
@@ -68,7 +137,7 @@ func (server *WorldServer) handleRequestOk(conn mnet.Server, reader mpacket.Read
 }
 
 func (server *WorldServer) handleRequestBad(conn mnet.Server, reader mpacket.Reader) {
-	log.Println("Rejected by login server at", conn)
+	log.Println("Rejected by login server at", conn, "-", reader.ReadString(reader.ReadInt16()))
 	timer := time.NewTimer(30 * time.Second)
 
 	<-timer.C
@@ -82,12 +151,6 @@ func (server *WorldServer) handleNewChannel(conn mnet.Server, reader mpacket.Rea
 	port := reader.ReadInt16()
 	maxPop := reader.ReadInt16()
 
-	if len(server.info.channels) > 19 {
-		p := mpacket.CreateInternal(opcode.ChannelBad)
-		conn.Send(p)
-		return
-	}
-
 	// check to see if we have lost any channels
 	for i, v := range server.info.channels {
 		if v.conn == nil {
@@ -97,16 +160,25 @@ func (server *WorldServer) handleNewChannel(conn mnet.Server, reader mpacket.Rea
 			server.info.channels[i].maxPop = maxPop
 
 			p := mpacket.CreateInternal(opcode.ChannelOk)
+			p.WriteString(server.info.name)
 			p.WriteByte(byte(i))
 			conn.Send(p)
+			conn.Send(server.rates.generatePacket())
 			server.login.Send(server.info.generateInfoPacket())
 
 			log.Println("Re-registered channel", i)
 			server.sendChannelInfo()
+			server.sendCashShopInfo()
 			return
 		}
 	}
 
+	if len(server.info.channels) >= server.maxChannels {
+		p := mpacket.CreateInternal(opcode.ChannelBad)
+		conn.Send(p)
+		return
+	}
+
 	newChannel := channel{conn: conn, ip: ip, port: port, maxPop: maxPop, pop: 0}
 	server.info.channels = append(server.info.channels, newChannel)
 
@@ -114,10 +186,94 @@ func (server *WorldServer) handleNewChannel(conn mnet.Server, reader mpacket.Rea
 	p.WriteString(server.info.name)
 	p.WriteByte(byte(len(server.info.channels) - 1))
 	conn.Send(p)
+	conn.Send(server.rates.generatePacket())
 	server.login.Send(server.info.generateInfoPacket())
 
 	log.Println("Registered channel", len(server.info.channels)-1)
 	server.sendChannelInfo()
+	server.sendCashShopInfo()
+}
+
+// handleDisconnectAccount from whichever channel or cash shop the account is on
+func (server *WorldServer) handleDisconnectAccount(conn mnet.Server, reader mpacket.Reader) {
+	p := mpacket.CreateInternal(opcode.DisconnectAccount)
+	p.WriteInt32(reader.ReadInt32())
+
+	for _, v := range server.info.channels {
+		if v.conn == nil {
+			continue
+		}
+
+		v.conn.Send(p)
+	}
+
+	if server.cashShop.conn != nil {
+		server.cashShop.conn.Send(p)
+	}
+}
+
+// handleMigrationTicket passes the ticket on to the channel or cash shop the client is moving to
+func (server *WorldServer) handleMigrationTicket(conn mnet.Server, reader mpacket.Reader) {
+	ticket := migration.Read(&reader)
+
+	if ticket.Target == constant.CashShopID {
+		if server.cashShop.conn != nil {
+			server.cashShop.conn.Send(ticket.Packet())
+		}
+
+		return
+	}
+
+	if int(ticket.Target) < len(server.info.channels) && server.info.channels[ticket.Target].conn != nil {
+		server.info.channels[ticket.Target].conn.Send(ticket.Packet())
+		return
+	}
+
+	log.Println("Dropped migration ticket for character", ticket.CharacterID, "to unknown channel", ticket.Target)
+}
+
+// handleChangeRates from a channel, all zero rates go back to the configured rates and a duration of zero is permanent
+// until the next reset
+func (server *WorldServer) handleChangeRates(conn mnet.Server, reader mpacket.Reader) {
+	var r rates
+	r.serialisePacket(&reader)
+	duration := time.Duration(reader.ReadInt64()) * time.Second
+
+	if r == (rates{}) {
+		server.rates = server.configuredRates
+		server.baseRates = server.configuredRates
+		server.ratesExpire = time.Time{}
+	} else {
+		r.sanitise()
+		server.rates = r
+
+		if duration > 0 {
+			server.ratesExpire = time.Now().Add(duration)
+		} else {
+			server.baseRates = r
+			server.ratesExpire = time.Time{}
+		}
+	}
+
+	log.Printf("Rates changed to exp %.2fx, drop %.2fx, mesos %.2fx", server.rates.exp, server.rates.drop, server.rates.mesos)
+
+	if !server.ratesExpire.IsZero() {
+		log.Println("Rates return to normal at", server.ratesExpire)
+	}
+
+	server.sendRates()
+}
+
+func (server *WorldServer) sendRates() {
+	p := server.rates.generatePacket()
+
+	for _, v := range server.info.channels {
+		if v.conn == nil {
+			continue
+		}
+
+		v.conn.Send(p)
+	}
 }
 
 func (server *WorldServer) sendChannelInfo() {
@@ -136,4 +292,50 @@ func (server *WorldServer) sendChannelInfo() {
 
 		v.conn.Send(p)
 	}
+
+	if server.cashShop.conn != nil {
+		server.cashShop.conn.Send(p)
+	}
+}
+
+func (server *WorldServer) handleNewCashShop(conn mnet.Server, reader mpacket.Reader) {
+	log.Println("New cash shop request")
+	ip := reader.ReadBytes(4)
+	port := reader.ReadInt16()
+
+	if server.cashShop.conn != nil {
+		p := mpacket.CreateInternal(opcode.CashShopBad)
+		conn.Send(p)
+		return
+	}
+
+	server.cashShop = channel{conn: conn, ip: ip, port: port}
+
+	p := mpacket.CreateInternal(opcode.CashShopOk)
+	p.WriteString(server.info.name)
+	conn.Send(p)
+
+	log.Println("Registered cash shop")
+	server.sendChannelInfo()
+	server.sendCashShopInfo()
+}
+
+func (server *WorldServer) sendCashShopInfo() {
+	p := mpacket.CreateInternal(opcode.CashShopInfo)
+
+	if server.cashShop.conn == nil {
+		p.WriteBytes([]byte{0, 0, 0, 0})
+		p.WriteInt16(0)
+	} else {
+		p.WriteBytes(server.cashShop.ip)
+		p.WriteInt16(server.cashShop.port)
+	}
+
+	for _, v := range server.info.channels {
+		if v.conn == nil {
+			continue
+		}
+
+		v.conn.Send(p)
+	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/Hucaru/Valhalla/constant"
 	"github.com/Hucaru/Valhalla/nx"
 	"github.com/Hucaru/Valhalla/server"
+	"github.com/Hucaru/Valhalla/server/metrics"
 
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
@@ -42,6 +43,7 @@ func newChannelServer(configFile string) *channelServer {
 
 func (cs *channelServer) run() {
 	log.Println("Channel Server")
+	metrics.Serve()
 
 	cs.establishWorldConnection()
 
@@ -52,9 +54,11 @@ func (cs *channelServer) run() {
 	log.Println("Loaded and parsed Wizet data (NX) in", elapsed)
 
 	cs.gameState.Initialise(cs.wRecv, cs.dbConfig.User, cs.dbConfig.Password, cs.dbConfig.Address, cs.dbConfig.Port, cs.dbConfig.Database)
+	cs.gameState.SetAttackViolationAction(cs.config.AttackViolation)
 
 	go script.WatchScriptDirectory("scripts/npc/")
 	go script.WatchScriptDirectory("scripts/event/")
+	go script.WatchScriptDirectory("scripts/portal/")
 	go script.WatchScriptDirectory("scripts/admin/")
 
 	cs.wg.Add(1)
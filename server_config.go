@@ -20,9 +20,20 @@ type loginConfig struct {
 	ServerListenAddress string
 	ServerListenPort    string
 	PacketQueueSize     int
+	PinEnabled          bool
+
+	AutoRegister              bool
+	RegistrationListenAddress string
+	RegistrationListenPort    string // registration endpoint is disabled when empty
 }
 
 type worldConfig struct {
+	ID              byte
+	Name            string
+	MaxChannels     int
+	ExpRate         float32
+	DropRate        float32
+	MesosRate       float32
 	Message         string
 	Ribbon          byte
 	LoginAddress    string
@@ -40,6 +51,16 @@ type channelConfig struct {
 	ListenPort              string
 	PacketQueueSize         int
 	MaxPop                  int16
+	AttackViolation         string
+}
+
+type cashShopConfig struct {
+	WorldAddress            string
+	WorldPort               string
+	ListenAddress           string
+	ClientConnectionAddress string
+	ListenPort              string
+	PacketQueueSize         int
 }
 
 type fullConfig struct {
@@ -47,6 +68,7 @@ type fullConfig struct {
 	Login    loginConfig
 	World    worldConfig
 	Channel  channelConfig
+	CashShop cashShopConfig
 }
 
 func loginConfigFromFile(fname string) (loginConfig, dbConfig) {
@@ -78,3 +100,23 @@ func channelConfigFromFile(fname string) (channelConfig, dbConfig) {
 
 	return config.Channel, config.Database
 }
+
+func cashShopConfigFromFile(fname string) (cashShopConfig, dbConfig) {
+	config := &fullConfig{}
+
+	if _, err := toml.DecodeFile(fname, config); err != nil {
+		log.Fatal(err)
+	}
+
+	return config.CashShop, config.Database
+}
+
+func adminConfigFromFile(fname string) dbConfig {
+	config := &fullConfig{}
+
+	if _, err := toml.DecodeFile(fname, config); err != nil {
+		log.Fatal(err)
+	}
+
+	return config.Database
+}
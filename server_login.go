@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"sync"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/nx"
 	"github.com/Hucaru/Valhalla/server"
+	"github.com/Hucaru/Valhalla/server/metrics"
 
 	"github.com/Hucaru/Valhalla/mnet"
 )
@@ -37,6 +39,7 @@ func newLoginServer(configFile string) *loginServer {
 
 func (ls *loginServer) run() {
 	log.Println("Login Server")
+	metrics.Serve()
 
 	start := time.Now()
 	nx.LoadFile("Data.nx")
@@ -45,6 +48,13 @@ func (ls *loginServer) run() {
 	log.Println("Loaded and parsed Wizet data (NX) in", elapsed)
 
 	ls.gameState.Initialise(ls.dbConfig.User, ls.dbConfig.Password, ls.dbConfig.Address, ls.dbConfig.Port, ls.dbConfig.Database)
+	ls.gameState.SetPinEnabled(ls.config.PinEnabled)
+	ls.gameState.SetAutoRegister(ls.config.AutoRegister)
+
+	if ls.config.RegistrationListenPort != "" {
+		ls.wg.Add(1)
+		go ls.serveRegistration()
+	}
 
 	ls.wg.Add(1)
 	go ls.acceptNewClientConnections()
@@ -58,6 +68,15 @@ func (ls *loginServer) run() {
 	ls.wg.Wait()
 }
 
+func (ls *loginServer) serveRegistration() {
+	defer ls.wg.Done()
+
+	address := ls.config.RegistrationListenAddress + ":" + ls.config.RegistrationListenPort
+	log.Println("Registration endpoint ready:", address)
+
+	log.Println(http.ListenAndServe(address, ls.gameState.RegistrationHandler()))
+}
+
 func (ls *loginServer) acceptNewServerConnections() {
 	defer ls.wg.Done()
 
@@ -125,8 +144,13 @@ func (ls *loginServer) acceptNewClientConnections() {
 func (ls *loginServer) processEvent() {
 	defer ls.wg.Done()
 
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
+		case t := <-ticker.C:
+			ls.gameState.Update(t)
 		case e, ok := <-ls.eRecv:
 
 			if !ok {
@@ -139,6 +163,7 @@ func (ls *loginServer) processEvent() {
 				switch e.Type {
 				case mnet.MEClientConnected:
 					log.Println("New client from", conn)
+					ls.gameState.ClientConnected(conn)
 				case mnet.MEClientDisconnect:
 					log.Println("Client at", conn, "disconnected")
 					ls.gameState.ClientDisconnected(conn)
@@ -10,6 +10,7 @@ import (
 	"github.com/Hucaru/Valhalla/mnet"
 	"github.com/Hucaru/Valhalla/mpacket"
 	"github.com/Hucaru/Valhalla/server"
+	"github.com/Hucaru/Valhalla/server/metrics"
 )
 
 type worldServer struct {
@@ -34,6 +35,10 @@ func newWorldServer(configFile string) *worldServer {
 
 func (ws *worldServer) run() {
 	log.Println("World Server")
+	metrics.Serve()
+
+	ws.state.SetRates(ws.config.ExpRate, ws.config.DropRate, ws.config.MesosRate)
+	ws.state.SetMaxChannels(ws.config.MaxChannels)
 
 	ws.establishLoginConnection()
 
@@ -53,7 +58,7 @@ func (ws *worldServer) establishLoginConnection() {
 	}
 	ticker.Stop()
 
-	ws.state.RegisterWithLogin(ws.lconn, ws.config.Message, ws.config.Ribbon)
+	ws.state.RegisterWithLogin(ws.lconn, ws.config.ID, ws.config.Name, ws.config.Message, ws.config.Ribbon)
 }
 
 func (ws *worldServer) connectToLogin() bool {
@@ -107,8 +112,13 @@ func (ws *worldServer) acceptNewServerConnections() {
 func (ws *worldServer) processEvent() {
 	defer ws.wg.Done()
 
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
+		case t := <-ticker.C:
+			ws.state.Update(t)
 		case e, ok := <-ws.eRecv:
 
 			if !ok {
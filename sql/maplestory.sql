@@ -10,15 +10,70 @@ CREATE TABLE `accounts` (
   `accountID` int(10) unsigned NOT NULL AUTO_INCREMENT,
   `username` tinytext NOT NULL,
   `password` tinytext NOT NULL,
+  `pin` tinytext DEFAULT NULL,
   `isLogedIn` tinyint(4) NOT NULL DEFAULT '0',
   `adminLevel` tinyint(4) NOT NULL DEFAULT '0',
   `isBanned` int(11) NOT NULL DEFAULT '0',
   `gender` tinyint(4) NOT NULL DEFAULT '0',
   `dob` int(11) NOT NULL,
+  `nx` int(11) NOT NULL DEFAULT '0',
+  `maplePoints` int(11) NOT NULL DEFAULT '0',
+  `registerIP` varchar(45) NOT NULL DEFAULT '',
+  `registeredAt` bigint(20) NOT NULL DEFAULT '0',
   PRIMARY KEY (`accountID`)
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
 
+DROP TABLE IF EXISTS `bans`;
+CREATE TABLE `bans` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `accountID` int(10) unsigned NOT NULL,
+  `ip` varchar(45) NOT NULL DEFAULT '',
+  `reason` tinyint(4) NOT NULL DEFAULT '1',
+  `gmName` tinytext NOT NULL,
+  `startTime` bigint(20) NOT NULL,
+  `endTime` bigint(20) DEFAULT NULL,
+  `active` tinyint(1) NOT NULL DEFAULT '1',
+  PRIMARY KEY (`id`),
+  KEY `accountID` (`accountID`),
+  KEY `ip` (`ip`),
+  CONSTRAINT `bans_ibfk_1` FOREIGN KEY (`accountID`) REFERENCES `accounts` (`accountID`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+DROP TABLE IF EXISTS `cashshop_gifts`;
+CREATE TABLE `cashshop_gifts` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `accountID` int(10) unsigned NOT NULL,
+  `sn` int(11) NOT NULL,
+  `itemID` int(11) NOT NULL,
+  `senderName` tinytext NOT NULL,
+  `message` tinytext NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `accountID` (`accountID`),
+  CONSTRAINT `cashshop_gifts_ibfk_1` FOREIGN KEY (`accountID`) REFERENCES `accounts` (`accountID`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+DROP TABLE IF EXISTS `cashshop_storage`;
+CREATE TABLE `cashshop_storage` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `accountID` int(10) unsigned NOT NULL,
+  `itemID` int(11) NOT NULL,
+  `amount` int(11) NOT NULL DEFAULT '1',
+  `sn` int(11) NOT NULL DEFAULT '0',
+  `expireTime` bigint(20) NOT NULL DEFAULT '0',
+  `gifterName` tinytext NOT NULL,
+  `petName` tinytext NOT NULL,
+  `petLevel` tinyint(4) NOT NULL DEFAULT '0',
+  `petCloseness` smallint(6) NOT NULL DEFAULT '0',
+  `petFullness` tinyint(4) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `accountID` (`accountID`),
+  CONSTRAINT `cashshop_storage_ibfk_1` FOREIGN KEY (`accountID`) REFERENCES `accounts` (`accountID`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
 DROP TABLE IF EXISTS `characters`;
 CREATE TABLE `characters` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
@@ -26,6 +81,7 @@ CREATE TABLE `characters` (
   `worldID` int(11) unsigned NOT NULL,
   `channelID` tinyint(2) NOT NULL DEFAULT '-1',
   `migrationID` tinyint(4) NOT NULL DEFAULT '-1',
+  `previousChannelID` tinyint(4) NOT NULL DEFAULT '-1',
   `name` tinytext NOT NULL,
   `gender` int(11) unsigned NOT NULL,
   `skin` int(11) unsigned NOT NULL,
@@ -44,7 +100,7 @@ CREATE TABLE `characters` (
   `ap` int(11) unsigned NOT NULL DEFAULT '0',
   `sp` int(11) unsigned NOT NULL DEFAULT '0',
   `exp` int(11) unsigned NOT NULL DEFAULT '0',
-  `fame` int(11) unsigned NOT NULL DEFAULT '0',
+  `fame` int(11) NOT NULL DEFAULT '0',
   `mapID` int(11) unsigned NOT NULL DEFAULT '0',
   `mapPos` int(11) unsigned NOT NULL DEFAULT '0',
   `previousMapID` int(11) unsigned NOT NULL DEFAULT '0',
@@ -58,12 +114,26 @@ CREATE TABLE `characters` (
   `miniGameDraw` int(11) NOT NULL DEFAULT '0',
   `miniGameLoss` int(11) NOT NULL DEFAULT '0',
   `miniGamePoints` int(11) NOT NULL DEFAULT '2000',
+  `deletedAt` bigint(20) DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `userID` (`accountID`),
   CONSTRAINT `characters_ibfk_1` FOREIGN KEY (`accountID`) REFERENCES `accounts` (`accountID`)
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
 
+DROP TABLE IF EXISTS `fame_log`;
+CREATE TABLE `fame_log` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `fromID` int(11) NOT NULL,
+  `toID` int(11) NOT NULL,
+  `time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fromID` (`fromID`),
+  CONSTRAINT `fame_log_ibfk_1` FOREIGN KEY (`fromID`) REFERENCES `characters` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fame_log_ibfk_2` FOREIGN KEY (`toID`) REFERENCES `characters` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
 DROP TABLE IF EXISTS `items`;
 CREATE TABLE `items` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
@@ -98,6 +168,30 @@ CREATE TABLE `items` (
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
 
+DROP TABLE IF EXISTS `login_lockouts`;
+CREATE TABLE `login_lockouts` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `lockKey` tinytext NOT NULL,
+  `username` tinytext NOT NULL,
+  `ip` varchar(45) NOT NULL DEFAULT '',
+  `lockedAt` bigint(20) NOT NULL,
+  `lockedUntil` bigint(20) NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
+DROP TABLE IF EXISTS `pets`;
+CREATE TABLE `pets` (
+  `id` int(11) NOT NULL,
+  `name` tinytext NOT NULL,
+  `level` tinyint(4) NOT NULL DEFAULT '1',
+  `closeness` smallint(6) NOT NULL DEFAULT '0',
+  `fullness` tinyint(4) NOT NULL DEFAULT '100',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `pets_ibfk_1` FOREIGN KEY (`id`) REFERENCES `items` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+
 DROP TABLE IF EXISTS `skills`;
 CREATE TABLE `skills` (
   `id` int(11) NOT NULL AUTO_INCREMENT,